@@ -6,25 +6,60 @@ import (
 	"fmt"
 )
 
-// This function is just to append the simulator transactions to the DB
-func AddtransactionsToDB() {
+// AddTxnsConfig holds the parameters of the add-transactions scenario
+type AddTxnsConfig struct {
+	TotalTransactions int // Transactions to generate and append
+	TxnsPerBlock      int // Transactions per block
+	StartBlockNumber  int // Block number of the first generated block
+	BatchSize         int // Batch size for inserts (0 = use default from InsertRecords)
+}
 
-	// Get the transaction from the function generateBlockBasedTransactions
-	transactions := generateBlockBasedTransactions(100000, 200, 50)
-	tableOps := IMMUSQL.GetTableOps()
-	// Add the transactions to the DB
-	err := tableOps.InsertRecords(context.Background(), transactions)
+// DefaultAddTxnsConfig returns the default add-transactions configuration
+func DefaultAddTxnsConfig() AddTxnsConfig {
+	return AddTxnsConfig{
+		TotalTransactions: 100000,
+		TxnsPerBlock:      200,
+		StartBlockNumber:  50,
+	}
+}
+
+// AddtransactionsToDB appends simulator transactions to the table, creating it first if needed
+// A table that already has records is continued: blocks after its tail, and transfers the run seed
+// has not written to it yet (see Generator.Resume)
+func AddtransactionsToDB(config AddTxnsConfig) error {
+	ctx := context.Background()
+
+	tableOps := IMMUSQL.GetTableOps(appSettings).WithBatchSize(config.BatchSize)
+	if err := tableOps.CreateTable(ctx); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	tail, tailID, err := tableOps.GetTailRecord(ctx)
 	if err != nil {
-		fmt.Printf("Failed to add transactions to the DB: %v\n", err)
-		return
+		return err
+	}
+
+	// Generate the transactions from the run seed, after the existing ones
+	gen := newGenerator()
+	startBlock := config.StartBlockNumber
+	if tail != nil {
+		// The tail id only grows, even when records were deleted, so no two appends share a stream
+		gen.Resume(int(tailID), tail.Timestamp)
+		startBlock = max(startBlock, tail.BlockNumber+1)
+		fmt.Printf("Continuing table '%s' after record %d, from block %d\n", tableOps.TableName(), tailID, startBlock)
+	}
+	transactions := gen.BlockTransactions(config.TotalTransactions, config.TxnsPerBlock, startBlock)
+	// Add the transactions to the DB
+	if err := tableOps.InsertRecords(ctx, transactions); err != nil {
+		return fmt.Errorf("failed to add transactions to the DB: %w", err)
 	}
 
 	fmt.Println("Transactions added to the DB successfully. Printing Head 5 and Tail 5 transactions:")
 	// Print the first 5 and last 5 transactions
-	for i := 0; i < 5; i++ {
+	for i := 0; i < min(5, len(transactions)); i++ {
 		fmt.Printf("Transaction %d: %+v\n", i+1, transactions[i])
 	}
-	for i := len(transactions) - 5; i < len(transactions); i++ {
+	for i := max(5, len(transactions)-5); i < len(transactions); i++ {
 		fmt.Printf("Transaction %d: %+v\n", i+1, transactions[i])
 	}
-}
\ No newline at end of file
+	return nil
+}
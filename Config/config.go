@@ -1,14 +1,238 @@
 package Config
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Default connection settings, used unless a config file, the environment or a flag overrides them
+const (
+	DefaultHost     = "localhost"
+	DefaultPort     = 3322
+	DefaultUser     = "immudb"
+	DefaultPassword = "immudb"
+	DefaultDatabase = "historydb"
+	DefaultTable    = "historytable"
+)
+
+// Address distributions of the generated test data (see Generator)
+const (
+	DistributionCycle   = "cycle"   // the five fixed test addresses in turn (the original dataset)
+	DistributionUniform = "uniform" // every address equally likely
+	DistributionZipf    = "zipf"    // address k is picked with probability ~ 1/k^ZipfS
+	DistributionHotSet  = "hotset"  // HotSetShare of the picks go to the first HotSetSize addresses
+)
+
+// DefaultProfileName is the name of the connection described by the top-level settings
+const DefaultProfileName = "default"
+
+// Connection backends
 const (
-	ImmuDBHost     = "localhost"
-	ImmuDBPort     = 3322
-	ImmuDBUser     = "immudb"
-	ImmuDBPassword = "immudb"
-	ImmuDBDatabase = "historydb"
-	ImmuDBTable    = "historytable"
+	BackendRemote   = "remote"   // connect to an immudb server at Host:Port (default)
+	BackendEmbedded = "embedded" // start immudb in-process on Dir (a temp dir when empty); no server needed
 )
 
+// TLSOptions holds the mutual TLS settings of a connection profile
+type TLSOptions struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled"`
+	ServerName  string `yaml:"server_name" toml:"server_name"`
+	Certificate string `yaml:"certificate" toml:"certificate"`
+	PrivateKey  string `yaml:"private_key" toml:"private_key"`
+	ClientCAs   string `yaml:"client_cas" toml:"client_cas"`
+}
+
+// TrustedStateMemory as Profile.TrustedState keeps the trusted state in memory only (tests, throwaway servers)
+const TrustedStateMemory = "memory"
+
+// Profile describes how to reach one immudb instance
+type Profile struct {
+	Name     string     `yaml:"-" toml:"-"`
+	Backend  string     `yaml:"backend" toml:"backend"`
+	Dir      string     `yaml:"dir" toml:"dir"`
+	Host     string     `yaml:"host" toml:"host"`
+	Port     int        `yaml:"port" toml:"port"`
+	User     string     `yaml:"user" toml:"user"`
+	Password string     `yaml:"password" toml:"password"`
+	Database string     `yaml:"database" toml:"database"`
+	TLS      TLSOptions `yaml:"tls" toml:"tls"`
+
+	// TrustedState is the file keeping the last verified database state between runs (see IMMUDB.StateStore)
+	// Empty uses the default file, TrustedStateMemory keeps it in memory only
+	TrustedState string `yaml:"trusted_state" toml:"trusted_state"`
+}
+
+// AddressSettings shapes the addresses of the generated test data
+// Senders and recipients are drawn from Count accounts with Distribution; recipients can also be
+// one of Contracts contract addresses (ContractCallRatio of transfers) or missing entirely for a
+// contract creation (CreationRatio of transfers, stored as a NULL toAddr)
+type AddressSettings struct {
+	Count             int     `yaml:"count" toml:"count" json:"count"`
+	Distribution      string  `yaml:"distribution" toml:"distribution" json:"distribution"`
+	ZipfS             float64 `yaml:"zipf_s" toml:"zipf_s" json:"zipfS"`
+	HotSetSize        int     `yaml:"hot_set_size" toml:"hot_set_size" json:"hotSetSize"`
+	HotSetShare       float64 `yaml:"hot_set_share" toml:"hot_set_share" json:"hotSetShare"`
+	Contracts         int     `yaml:"contracts" toml:"contracts" json:"contracts"`
+	ContractCallRatio float64 `yaml:"contract_call_ratio" toml:"contract_call_ratio" json:"contractCallRatio"`
+	CreationRatio     float64 `yaml:"creation_ratio" toml:"creation_ratio" json:"creationRatio"`
+}
+
+// Validate checks that the address settings describe a distribution that can be sampled
+func (a AddressSettings) Validate() error {
+	switch a.Distribution {
+	case DistributionCycle:
+		return nil // fixed addresses, the other fields are not used
+	case DistributionUniform, DistributionHotSet:
+	case DistributionZipf:
+		if a.ZipfS <= 1 {
+			return fmt.Errorf("zipf_s must be greater than 1, got %v", a.ZipfS)
+		}
+	default:
+		return fmt.Errorf("invalid address distribution: %q (use %s, %s, %s or %s)", a.Distribution,
+			DistributionCycle, DistributionUniform, DistributionZipf, DistributionHotSet)
+	}
+	if a.Count <= 0 {
+		return fmt.Errorf("address count must be positive, got %d", a.Count)
+	}
+	if a.Distribution == DistributionHotSet && (a.HotSetSize <= 0 || a.HotSetSize > a.Count) {
+		return fmt.Errorf("hot_set_size must be between 1 and the address count, got %d", a.HotSetSize)
+	}
+	if a.Contracts < 0 {
+		return fmt.Errorf("contracts must not be negative, got %d", a.Contracts)
+	}
+	for _, r := range []struct {
+		name  string
+		ratio float64
+	}{
+		{"hot_set_share", a.HotSetShare},
+		{"contract_call_ratio", a.ContractCallRatio},
+		{"creation_ratio", a.CreationRatio},
+	} {
+		if r.ratio < 0 || r.ratio > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", r.name, r.ratio)
+		}
+	}
+	if a.ContractCallRatio+a.CreationRatio > 1 {
+		return errors.New("contract_call_ratio and creation_ratio add up to more than 1")
+	}
+	return nil
+}
+
+// Settings holds the connection and table settings used by IMMUDB and IMMUSQL
+// Build it with Load so defaults, config file, environment and flags are all applied
+// The embedded Profile is the "default" connection; Profiles holds additional named ones
+// which inherit any field they leave empty from the default connection
+type Settings struct {
+	Profile       `yaml:",inline" toml:",inline"`
+	Table         string              `yaml:"table" toml:"table"`
+	ActiveProfile string              `yaml:"profile" toml:"profile"`
+	Profiles      map[string]*Profile `yaml:"profiles" toml:"profiles"`
+
+	// ServerTimestamps writes the server's NOW() into ts instead of Transfer.Timestamp
+	ServerTimestamps bool `yaml:"server_timestamps" toml:"server_timestamps"`
+
+	// Seed drives the test data generator; 0 picks a random seed at startup
+	Seed uint64 `yaml:"seed" toml:"seed"`
+	// Addresses shapes the senders and recipients of the generated test data
+	Addresses AddressSettings `yaml:"addresses" toml:"addresses"`
+}
+
+// Default returns the settings used when nothing overrides them
+func Default() *Settings {
+	return &Settings{
+		Profile: Profile{
+			Name:     DefaultProfileName,
+			Host:     DefaultHost,
+			Port:     DefaultPort,
+			User:     DefaultUser,
+			Password: DefaultPassword,
+			Database: DefaultDatabase,
+		},
+		Table: DefaultTable,
+		// The original dataset, so default runs stay comparable with earlier ones; the other
+		// fields shape the opt-in distributions (-address-dist, addresses.distribution)
+		Addresses: AddressSettings{
+			Count:             10000,
+			Distribution:      DistributionCycle,
+			ZipfS:             1.2, // a few very active accounts, a long tail of rare ones
+			HotSetSize:        100,
+			HotSetShare:       0.8,
+			Contracts:         100,
+			ContractCallRatio: 0.3,
+			CreationRatio:     0.01,
+		},
+	}
+}
+
+// Embedded reports whether the profile runs immudb in-process instead of connecting to a server
+func (p *Profile) Embedded() bool {
+	return p.Backend == BackendEmbedded
+}
+
+// Active returns the connection profile selected with -profile / IMMUDB_PROFILE (default if unset)
+func (s *Settings) Active() (*Profile, error) {
+	return s.Resolve(s.ActiveProfile)
+}
+
+// Resolve returns a copy of the named profile with empty fields inherited from the default profile
+// An empty name or "default" returns the default profile
+func (s *Settings) Resolve(name string) (*Profile, error) {
+	base := s.Profile
+	base.Name = DefaultProfileName
+	if name == "" || name == DefaultProfileName {
+		return &base, nil
+	}
+
+	named, ok := s.Profiles[name]
+	if !ok || named == nil {
+		return nil, fmt.Errorf("unknown connection profile: %q", name)
+	}
+
+	resolved := *named
+	resolved.Name = name
+	if resolved.Backend == "" {
+		resolved.Backend = base.Backend
+	}
+	// Dir is not inherited: two embedded profiles must not open the same data directory
+	if resolved.Host == "" {
+		resolved.Host = base.Host
+	}
+	if resolved.Port == 0 {
+		resolved.Port = base.Port
+	}
+	if resolved.User == "" {
+		resolved.User = base.User
+	}
+	if resolved.Password == "" {
+		resolved.Password = base.Password
+	}
+	if resolved.Database == "" {
+		resolved.Database = base.Database
+	}
+	if resolved.TLS == (TLSOptions{}) {
+		resolved.TLS = base.TLS
+	}
+	if resolved.TrustedState == "" {
+		resolved.TrustedState = base.TrustedState
+	}
+	return &resolved, nil
+}
+
+// ProfileNames returns the default profile followed by the named profiles, sorted
+func (s *Settings) ProfileNames() []string {
+	names := make([]string, 0, len(s.Profiles)+1)
+	names = append(names, DefaultProfileName)
+	for name := range s.Profiles {
+		if name != DefaultProfileName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Transfer is one row of the transfer table
+// To is empty for a contract creation and stored as a NULL toAddr
 type Transfer struct {
 	From            string `json:"from"`
 	To              string `json:"to"`
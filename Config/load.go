@@ -0,0 +1,330 @@
+package Config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+- Settings are resolved in four layers, each one overriding the previous:
+  1. Built-in defaults (see Default)
+  2. Optional config file (YAML or TOML, chosen by extension), given by -config or IMMUDB_CONFIG
+  3. IMMUDB_* environment variables
+  4. Command-line flags
+
+Connection flags and IMMUDB_* variables apply to the active profile (-profile / IMMUDB_PROFILE),
+or to the top-level (default) connection when no profile is selected.
+
+Example config file (YAML):
+
+	host: localhost
+	port: 3322
+	user: immudb
+	password: immudb
+	database: historydb
+	table: historytable
+	profiles:
+	  staging:
+	    host: immudb-staging.internal
+	    password: secret
+	    tls:
+	      enabled: true
+	      server_name: immudb-staging.internal
+	      certificate: certs/client.pem
+	      private_key: certs/client.key
+	      client_cas: certs/ca-chain.pem
+*/
+
+// Environment variables read by Load
+const (
+	EnvConfigFile       = "IMMUDB_CONFIG"
+	EnvProfile          = "IMMUDB_PROFILE"
+	EnvHost             = "IMMUDB_HOST"
+	EnvPort             = "IMMUDB_PORT"
+	EnvUser             = "IMMUDB_USER"
+	EnvPassword         = "IMMUDB_PASSWORD"
+	EnvDatabase         = "IMMUDB_DATABASE"
+	EnvTable            = "IMMUDB_TABLE"
+	EnvBackend          = "IMMUDB_BACKEND"
+	EnvDir              = "IMMUDB_DIR"
+	EnvServerTimestamps = "IMMUDB_SERVER_TIMESTAMPS"
+	EnvSeed             = "IMMUDB_SEED"
+	EnvAddresses        = "IMMUDB_ADDRESSES"
+	EnvAddressDist      = "IMMUDB_ADDRESS_DIST"
+	EnvTrustedState     = "IMMUDB_TRUSTED_STATE"
+)
+
+// identifierPattern matches names that are safe to splice into SQL as database or table names
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidIdentifier reports whether name can be used as a database or table name
+func ValidIdentifier(name string) bool {
+	return identifierPattern.MatchString(name)
+}
+
+// Load builds Settings from defaults, an optional config file, IMMUDB_* environment variables and flags
+// args are the command-line arguments without the program name. Flags are parsed up to the first
+// non-flag argument; the remaining arguments (the command and its own arguments) are returned.
+func Load(args []string) (*Settings, []string, error) {
+	fs := flag.NewFlagSet("simulator", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a YAML or TOML config file (env "+EnvConfigFile+")")
+	profile := fs.String("profile", "", "named connection profile from the config file (env "+EnvProfile+")")
+	backend := fs.String("backend", "", "remote or embedded (in-process immudb, no server needed) (env "+EnvBackend+")")
+	dir := fs.String("dir", "", "data directory for the embedded backend, a temp dir when empty (env "+EnvDir+")")
+	host := fs.String("host", "", "immudb host (env "+EnvHost+")")
+	port := fs.Int("port", 0, "immudb port (env "+EnvPort+")")
+	user := fs.String("user", "", "immudb user (env "+EnvUser+")")
+	password := fs.String("password", "", "immudb password (env "+EnvPassword+")")
+	database := fs.String("database", "", "immudb database (env "+EnvDatabase+")")
+	table := fs.String("table", "", "table name (env "+EnvTable+")")
+	trustedState := fs.String("trusted-state", "", "file keeping the last verified state between runs, or \""+TrustedStateMemory+"\" (env "+EnvTrustedState+")")
+	serverTimestamps := fs.Bool("server-timestamps", false, "write the server's NOW() into ts instead of the record timestamp (env "+EnvServerTimestamps+")")
+	seed := fs.Uint64("seed", 0, "seed of the test data generator, 0 = random (env "+EnvSeed+")")
+	addresses := fs.Int("addresses", 0, "number of accounts in the generated data (env "+EnvAddresses+")")
+	addressDist := fs.String("address-dist", "", "address distribution: cycle, uniform, zipf or hotset (env "+EnvAddressDist+")")
+	zipfS := fs.Float64("zipf-s", 0, "zipf exponent of the zipf address distribution, > 1")
+	hotSetSize := fs.Int("hot-set", 0, "accounts in the hot set of the hotset distribution")
+	hotSetShare := fs.Float64("hot-share", 0, "share of picks going to the hot set (0-1)")
+	contracts := fs.Int("contracts", 0, "number of contract addresses transfers can be sent to")
+	contractCalls := fs.Float64("contract-calls", 0, "share of transfers sent to a contract (0-1)")
+	creations := fs.Float64("creations", 0, "share of transfers creating a contract, with a NULL toAddr (0-1)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	settings := Default()
+
+	// 2. Config file
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := loadFile(path, settings); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Select the active profile before applying overrides, so they land on the right connection
+	if v, ok := os.LookupEnv(EnvProfile); ok {
+		settings.ActiveProfile = v
+	}
+	if *profile != "" {
+		settings.ActiveProfile = *profile
+	}
+	target, err := settings.overrideTarget()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Environment
+	if err := applyEnv(settings, target); err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Flags - only the ones explicitly set on the command line
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "backend":
+			target.Backend = *backend
+		case "dir":
+			target.Dir = *dir
+		case "host":
+			target.Host = *host
+		case "port":
+			target.Port = *port
+		case "user":
+			target.User = *user
+		case "password":
+			target.Password = *password
+		case "database":
+			target.Database = *database
+		case "table":
+			settings.Table = *table
+		case "trusted-state":
+			target.TrustedState = *trustedState
+		case "server-timestamps":
+			settings.ServerTimestamps = *serverTimestamps
+		case "seed":
+			settings.Seed = *seed
+		case "addresses":
+			settings.Addresses.Count = *addresses
+		case "address-dist":
+			settings.Addresses.Distribution = *addressDist
+		case "zipf-s":
+			settings.Addresses.ZipfS = *zipfS
+		case "hot-set":
+			settings.Addresses.HotSetSize = *hotSetSize
+		case "hot-share":
+			settings.Addresses.HotSetShare = *hotSetShare
+		case "contracts":
+			settings.Addresses.Contracts = *contracts
+		case "contract-calls":
+			settings.Addresses.ContractCallRatio = *contractCalls
+		case "creations":
+			settings.Addresses.CreationRatio = *creations
+		}
+	})
+
+	if err := settings.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return settings, fs.Args(), nil
+}
+
+// Validate checks that every profile can be used to connect and that the table name is safe for SQL
+func (s *Settings) Validate() error {
+	if !identifierPattern.MatchString(s.Table) {
+		return fmt.Errorf("invalid table name: %q", s.Table)
+	}
+	if _, err := s.Active(); err != nil {
+		return err
+	}
+	if err := s.Addresses.Validate(); err != nil {
+		return fmt.Errorf("addresses: %w", err)
+	}
+	for _, name := range s.ProfileNames() {
+		p, err := s.Resolve(name)
+		if err != nil {
+			return err
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the profile has everything needed to connect
+func (p *Profile) Validate() error {
+	switch p.Backend {
+	case "", BackendRemote:
+	case BackendEmbedded:
+		// In-process: host, port and TLS are not used
+		if !identifierPattern.MatchString(p.Database) {
+			return fmt.Errorf("invalid database name: %q", p.Database)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid backend: %q (use %s or %s)", p.Backend, BackendRemote, BackendEmbedded)
+	}
+	if p.Host == "" {
+		return errors.New("host must not be empty")
+	}
+	if p.Port <= 0 || p.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", p.Port)
+	}
+	if !identifierPattern.MatchString(p.Database) {
+		return fmt.Errorf("invalid database name: %q", p.Database)
+	}
+	if p.TLS.Enabled && (p.TLS.Certificate == "" || p.TLS.PrivateKey == "" || p.TLS.ClientCAs == "") {
+		return errors.New("tls requires certificate, private_key and client_cas")
+	}
+	return nil
+}
+
+// overrideTarget returns the profile that env vars and flags should modify
+func (s *Settings) overrideTarget() (*Profile, error) {
+	if s.ActiveProfile == "" || s.ActiveProfile == DefaultProfileName {
+		return &s.Profile, nil
+	}
+	p, ok := s.Profiles[s.ActiveProfile]
+	if !ok || p == nil {
+		return nil, fmt.Errorf("unknown connection profile: %q", s.ActiveProfile)
+	}
+	return p, nil
+}
+
+// loadFile decodes a YAML or TOML config file on top of the given settings
+// Fields missing from the file keep their current values
+func loadFile(path string, settings *Settings) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, settings)
+	case ".toml":
+		err = toml.Unmarshal(data, settings)
+	default:
+		return fmt.Errorf("unsupported config file format: %s (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides settings with any IMMUDB_* environment variables that are set
+// Connection variables are applied to target, the active profile
+func applyEnv(settings *Settings, target *Profile) error {
+	if v, ok := os.LookupEnv(EnvBackend); ok {
+		target.Backend = v
+	}
+	if v, ok := os.LookupEnv(EnvDir); ok {
+		target.Dir = v
+	}
+	if v, ok := os.LookupEnv(EnvHost); ok {
+		target.Host = v
+	}
+	if v, ok := os.LookupEnv(EnvPort); ok {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvPort, err)
+		}
+		target.Port = port
+	}
+	if v, ok := os.LookupEnv(EnvUser); ok {
+		target.User = v
+	}
+	if v, ok := os.LookupEnv(EnvPassword); ok {
+		target.Password = v
+	}
+	if v, ok := os.LookupEnv(EnvDatabase); ok {
+		target.Database = v
+	}
+	if v, ok := os.LookupEnv(EnvTable); ok {
+		settings.Table = v
+	}
+	if v, ok := os.LookupEnv(EnvTrustedState); ok {
+		target.TrustedState = v
+	}
+	if v, ok := os.LookupEnv(EnvServerTimestamps); ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvServerTimestamps, err)
+		}
+		settings.ServerTimestamps = enabled
+	}
+	if v, ok := os.LookupEnv(EnvSeed); ok {
+		seed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSeed, err)
+		}
+		settings.Seed = seed
+	}
+	if v, ok := os.LookupEnv(EnvAddresses); ok {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvAddresses, err)
+		}
+		settings.Addresses.Count = count
+	}
+	if v, ok := os.LookupEnv(EnvAddressDist); ok {
+		settings.Addresses.Distribution = v
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package Generator
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"DBTests/Config"
+)
+
+/*
+- Address universe of the generated data: Count accounts and Contracts contract addresses,
+  derived from the seed so a seed always names the same addresses
+- Account k (k = 0 is the most popular) is drawn with the configured distribution:
+  uniform, zipf (~ 1/k^ZipfS) or hotset (HotSetShare of picks among the first HotSetSize)
+- Recipients are an account, a contract (ContractCallRatio) or empty for a contract creation
+  (CreationRatio); contracts follow the same skew as accounts under zipf, uniform otherwise
+- The cycle distribution reproduces the original dataset: the five TestAddresses in turn
+- Query targets come from QueryAddresses, which samples the same distribution as the data,
+  so popular addresses are queried as often as they transact
+*/
+
+// Addresses draws addresses from the universe described by Config.AddressSettings
+// Not safe for concurrent use; give each goroutine its own (see Generator.QueryAddresses)
+type Addresses struct {
+	config    Config.AddressSettings
+	seed      uint64
+	rng       *rand.Rand
+	accounts  *rand.Zipf // zipf only
+	contracts *rand.Zipf // zipf only, nil without contracts
+	next      int        // cycle only: index of the next test address
+}
+
+// newAddresses returns a sampler of the universe of seed drawing from rng
+func newAddresses(config Config.AddressSettings, seed uint64, rng *rand.Rand) *Addresses {
+	a := &Addresses{config: config, seed: seed, rng: rng}
+	if config.Distribution == Config.DistributionZipf {
+		a.accounts = rand.NewZipf(rng, config.ZipfS, 1, uint64(config.Count-1))
+		if config.Contracts > 0 {
+			a.contracts = rand.NewZipf(rng, config.ZipfS, 1, uint64(config.Contracts-1))
+		}
+	}
+	return a
+}
+
+// Cycle reports whether the addresses are the five fixed TestAddresses
+func (a *Addresses) Cycle() bool {
+	return a.config.Distribution == Config.DistributionCycle
+}
+
+// Account returns an account drawn from the distribution
+func (a *Addresses) Account() string {
+	if a.Cycle() {
+		address := TestAddresses[a.next%len(TestAddresses)]
+		a.next++
+		return address
+	}
+	return a.account(a.accountRank())
+}
+
+// Recipient returns the recipient of a transfer: an account, a contract, or "" for a contract creation
+func (a *Addresses) Recipient() string {
+	if a.Cycle() {
+		return a.Account()
+	}
+	r := a.rng.Float64()
+	switch {
+	case r < a.config.CreationRatio:
+		return ""
+	case r < a.config.CreationRatio+a.config.ContractCallRatio && a.config.Contracts > 0:
+		if a.contracts != nil {
+			return a.contract(int(a.contracts.Uint64()))
+		}
+		return a.contract(a.rng.IntN(a.config.Contracts))
+	default:
+		return a.Account()
+	}
+}
+
+// Distinct returns up to n different accounts drawn from the distribution, popular ones most likely
+// Fewer are returned when the universe is smaller than n
+func (a *Addresses) Distinct(n int) []string {
+	if a.Cycle() {
+		return TestAddresses[:min(n, len(TestAddresses))]
+	}
+	n = min(n, a.config.Count)
+	seen := make(map[string]bool, n)
+	addresses := make([]string, 0, n)
+	for attempts := 0; len(addresses) < n && attempts < n*100; attempts++ {
+		address := a.Account()
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// accountRank draws the popularity rank of an account (0 = most popular)
+func (a *Addresses) accountRank() int {
+	switch a.config.Distribution {
+	case Config.DistributionZipf:
+		return int(a.accounts.Uint64())
+	case Config.DistributionHotSet:
+		hot := a.config.HotSetSize
+		if hot >= a.config.Count || a.rng.Float64() < a.config.HotSetShare {
+			return a.rng.IntN(hot)
+		}
+		return hot + a.rng.IntN(a.config.Count-hot)
+	default:
+		return a.rng.IntN(a.config.Count)
+	}
+}
+
+// account returns the address of the account with popularity rank k
+func (a *Addresses) account(k int) string {
+	return deriveAddress(a.seed, uint64(k))
+}
+
+// contract returns the address of contract k, from a separate range of the universe
+func (a *Addresses) contract(k int) string {
+	return deriveAddress(a.seed, 1<<48|uint64(k))
+}
+
+// deriveAddress maps (seed, index) to a 42-character address with splitmix64
+func deriveAddress(seed, index uint64) string {
+	x := seed ^ index*0x9e3779b97f4a7c15
+	var parts [3]uint64
+	for i := range parts {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		parts[i] = z ^ z>>31
+	}
+	return fmt.Sprintf("0x%016x%016x%08x", parts[0], parts[1], parts[2]>>32)
+}
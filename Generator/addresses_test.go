@@ -0,0 +1,94 @@
+package Generator
+
+import (
+	"testing"
+
+	"DBTests/Config"
+)
+
+// counts draws n accounts and returns how often each address came up
+func counts(a *Addresses, n int) map[string]int {
+	seen := make(map[string]int)
+	for range n {
+		seen[a.Account()]++
+	}
+	return seen
+}
+
+func TestDistributions(t *testing.T) {
+	settings := Config.Default().Addresses
+	settings.Count = 1000
+	settings.HotSetSize = 10
+	settings.HotSetShare = 0.9
+
+	const draws = 20000
+	top := func(distribution string) (int, int) {
+		settings.Distribution = distribution
+		g := New(5, settings)
+		seen := counts(g.QueryAddresses(g.Stream(0)), draws)
+		best := 0
+		for _, n := range seen {
+			best = max(best, n)
+		}
+		return best, len(seen)
+	}
+
+	uniformTop, uniformDistinct := top(Config.DistributionUniform)
+	zipfTop, _ := top(Config.DistributionZipf)
+	hotTop, _ := top(Config.DistributionHotSet)
+	if uniformDistinct < 990 {
+		t.Errorf("uniform drew %d distinct accounts of 1000", uniformDistinct)
+	}
+	if zipfTop < 5*uniformTop {
+		t.Errorf("zipf top account = %d draws, uniform = %d; want a much hotter head", zipfTop, uniformTop)
+	}
+	// 90% of picks over 10 hot accounts: about 1800 each
+	if hotTop < 1500 || hotTop > 2100 {
+		t.Errorf("hotset top account = %d draws, want about 1800", hotTop)
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	settings := Config.Default().Addresses
+	settings.Distribution = Config.DistributionZipf
+	settings.ContractCallRatio = 0.3
+	settings.CreationRatio = 0.1
+	g := New(9, settings)
+
+	isContract := make(map[string]bool)
+	for k := range settings.Contracts {
+		isContract[g.parties.contract(k)] = true
+	}
+	creations, contracts := 0, 0
+	const draws = 10000
+	for range draws {
+		switch to := g.parties.Recipient(); {
+		case to == "":
+			creations++
+		case len(to) != 42:
+			t.Fatalf("recipient %q is not 42 characters", to)
+		case isContract[to]:
+			contracts++
+		}
+	}
+	if creations < 800 || creations > 1200 {
+		t.Errorf("%d contract creations in %d transfers, want about 1000", creations, draws)
+	}
+	if contracts < 2500 || contracts > 3500 {
+		t.Errorf("%d contract calls in %d transfers, want about 3000", contracts, draws)
+	}
+}
+
+func TestCycleKeepsTestAddresses(t *testing.T) {
+	settings := Config.Default().Addresses
+	settings.Distribution = Config.DistributionCycle
+	transfers := New(3, settings).BlockTransactions(10, 5, 1)
+	for i, tx := range transfers {
+		if tx.From != TestAddresses[i%5] || tx.To != TestAddresses[(i+1)%5] {
+			t.Fatalf("transfer %d: %s -> %s, want the cycle of test addresses", i, tx.From, tx.To)
+		}
+	}
+	if got := New(3, settings).QueryAddresses(New(3, settings).Stream(0)).Distinct(10); len(got) != len(TestAddresses) {
+		t.Errorf("Distinct over the cycle = %d addresses, want %d", len(got), len(TestAddresses))
+	}
+}
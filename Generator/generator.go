@@ -0,0 +1,183 @@
+package Generator
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+
+	"DBTests/Config"
+)
+
+/*
+- Deterministic test data: every transfer, hash, block number and query choice comes from a
+  PRNG seeded with Seed, so the same seed gives a byte-identical dataset and query sequence
+- Hashes come from a ChaCha8 stream; math/rand/v2 pins both ChaCha8 and PCG, so a seed keeps
+  producing the same data across Go versions and platforms
+- Timestamps start at BaseTime and advance one second per generated transfer, across calls, so
+  blocks generated one after the other (e.g. by the mixed workload's writer) stay in order
+- Stream returns independent PRNGs for query sequences (one per worker), which do not depend on
+  how much data was generated before them
+- Senders and recipients come from the address universe in addresses.go
+*/
+
+// BaseTime is the timestamp of the first generated transfer (2023-11-14T22:13:20Z)
+const BaseTime int64 = 1700000000
+
+// TestAddresses are real Ethereum addresses for testing (42 characters each: 0x + 40 hex)
+// The cycle distribution uses only these
+var TestAddresses = []string{
+	"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+	"0x8ba1f109551bD432803012645Aac136c22C929E7",
+	"0x1234567890123456789012345678901234567890",
+	"0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+	"0xfedcba9876543210fedcba9876543210fedcba98",
+}
+
+// Generator produces deterministic transfers from a seed
+// A Generator is not safe for concurrent use; give each goroutine its own Stream
+type Generator struct {
+	seed      uint64
+	addresses Config.AddressSettings
+	hash      *rand.ChaCha8
+	rng       *rand.Rand
+	parties   *Addresses // senders and recipients of generated transfers
+	clock     int64      // timestamp of the next transfer
+}
+
+// New returns a generator seeded with seed, drawing addresses as configured
+func New(seed uint64, addresses Config.AddressSettings) *Generator {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	rng := rand.New(rand.NewPCG(seed, 0))
+	return &Generator{
+		seed:      seed,
+		addresses: addresses,
+		hash:      rand.NewChaCha8(key),
+		rng:       rng,
+		parties:   newAddresses(addresses, seed, rng),
+		clock:     BaseTime,
+	}
+}
+
+// RandomSeed returns a non-zero seed from the OS random source, for runs that did not ask for one
+func RandomSeed() uint64 {
+	var b [8]byte
+	crand.Read(b[:])
+	if seed := binary.LittleEndian.Uint64(b[:]); seed != 0 {
+		return seed
+	}
+	return 1
+}
+
+// Resume continues a dataset of which records transfers already exist, the last one at lastTimestamp
+// Hashes and parties are drawn from streams of their own for each count, so appending to a table never
+// repeats what the same seed already wrote to it; Resume(0, ...) draws the same data as a new generator
+func (g *Generator) Resume(records int, lastTimestamp int64) {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], g.seed)
+	binary.LittleEndian.PutUint64(key[8:], uint64(records))
+	g.hash = rand.NewChaCha8(key)
+	g.rng = rand.New(rand.NewPCG(g.seed, uint64(records)))
+	g.parties = newAddresses(g.addresses, g.seed, g.rng)
+	g.clock = max(g.clock, lastTimestamp+1)
+}
+
+// Seed returns the seed the generator was created with
+func (g *Generator) Seed() uint64 {
+	return g.seed
+}
+
+// Stream returns an independent PRNG for query sequence id
+// The same seed and id always give the same sequence, whatever else was generated
+func (g *Generator) Stream(id uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(g.seed, id+1)) // id+1: stream 0 is the generator's own
+}
+
+// QueryAddresses returns an address sampler drawing from rng (usually a Stream), over the same
+// universe and distribution as the generated transfers
+func (g *Generator) QueryAddresses(rng *rand.Rand) *Addresses {
+	return newAddresses(g.addresses, g.seed, rng)
+}
+
+// TransactionHash generates a realistic 66-character transaction hash
+func (g *Generator) TransactionHash() string {
+	bytes := make([]byte, 32) // 32 bytes = 64 hex chars
+	g.hash.Read(bytes)
+	return "0x" + hex.EncodeToString(bytes)
+}
+
+// BlockNumber generates a random block number between start and end, inclusive
+func (g *Generator) BlockNumber(start, end int) int {
+	return start + g.rng.IntN(end-start+1)
+}
+
+// TestTransactions generates count transactions in random blocks between blockMin and blockMax
+func (g *Generator) TestTransactions(count int, blockMin, blockMax int) []Config.Transfer {
+	transactions := make([]Config.Transfer, 0, count)
+
+	for i := 0; i < count; i++ {
+		from, to := g.transferParties(i)
+		transactions = append(transactions, Config.Transfer{
+			From:            from,
+			To:              to,
+			BlockNumber:     g.BlockNumber(blockMin, blockMax),
+			TransactionHash: g.TransactionHash(),
+			BlockHash:       g.TransactionHash(), // same format as a transaction hash
+			TxBlockIndex:    i % 100,             // Transaction index within block (0-99)
+			Timestamp:       g.tick(),
+		})
+	}
+
+	return transactions
+}
+
+// BlockTransactions generates transactions grouped by blocks (realistic pattern)
+// Each block has up to txnsPerBlock transactions, starting at startBlock
+func (g *Generator) BlockTransactions(totalTxns int, txnsPerBlock int, startBlock int) []Config.Transfer {
+	transactions := make([]Config.Transfer, 0, totalTxns)
+
+	currentBlock := startBlock
+	txnsInCurrentBlock := 0
+	blockHash := g.TransactionHash() // Same hash for all txns in a block
+
+	for i := 0; i < totalTxns; i++ {
+		// Start new block if current block is full
+		if txnsInCurrentBlock >= txnsPerBlock {
+			currentBlock++
+			txnsInCurrentBlock = 0
+			blockHash = g.TransactionHash()
+		}
+
+		from, to := g.transferParties(i)
+		transactions = append(transactions, Config.Transfer{
+			From:            from,
+			To:              to,
+			BlockNumber:     currentBlock,
+			TransactionHash: g.TransactionHash(),
+			BlockHash:       blockHash,
+			TxBlockIndex:    txnsInCurrentBlock,
+			Timestamp:       g.tick(),
+		})
+
+		txnsInCurrentBlock++
+	}
+
+	return transactions
+}
+
+// transferParties returns the sender and recipient of the i-th transfer of a call
+// The cycle distribution pairs test address i with the next one, as the original generator did
+func (g *Generator) transferParties(i int) (string, string) {
+	if g.parties.Cycle() {
+		return TestAddresses[i%len(TestAddresses)], TestAddresses[(i+1)%len(TestAddresses)]
+	}
+	return g.parties.Account(), g.parties.Recipient()
+}
+
+// tick returns the timestamp of the next transfer and advances the clock by one second
+func (g *Generator) tick() int64 {
+	ts := g.clock
+	g.clock++
+	return ts
+}
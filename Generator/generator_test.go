@@ -0,0 +1,96 @@
+package Generator
+
+import (
+	"reflect"
+	"testing"
+
+	"DBTests/Config"
+)
+
+func TestSameSeedSameData(t *testing.T) {
+	a, b := New(42, Config.Default().Addresses), New(42, Config.Default().Addresses)
+	if !reflect.DeepEqual(a.BlockTransactions(500, 40, 100), b.BlockTransactions(500, 40, 100)) {
+		t.Fatal("block transactions differ for the same seed")
+	}
+	if !reflect.DeepEqual(a.TestTransactions(500, 1, 1000), b.TestTransactions(500, 1, 1000)) {
+		t.Fatal("test transactions differ for the same seed")
+	}
+
+	c := New(43, Config.Default().Addresses).BlockTransactions(500, 40, 100)
+	if reflect.DeepEqual(New(42, Config.Default().Addresses).BlockTransactions(500, 40, 100), c) {
+		t.Fatal("different seeds produced the same transactions")
+	}
+}
+
+func TestResume(t *testing.T) {
+	settings := Config.Default().Addresses
+	first := New(42, settings).BlockTransactions(500, 40, 100)
+
+	fresh := New(42, settings)
+	fresh.Resume(0, 0)
+	if !reflect.DeepEqual(fresh.BlockTransactions(500, 40, 100), first) {
+		t.Fatal("resuming an empty dataset changed the data")
+	}
+
+	g := New(42, settings)
+	g.Resume(len(first), first[len(first)-1].Timestamp)
+	next := g.BlockTransactions(500, 40, 113)
+	hashes := make(map[string]bool)
+	for _, tx := range first {
+		hashes[tx.TransactionHash] = true
+	}
+	for _, tx := range next {
+		if hashes[tx.TransactionHash] {
+			t.Fatalf("resumed data repeats hash %s", tx.TransactionHash)
+		}
+	}
+	if next[0].Timestamp != first[len(first)-1].Timestamp+1 {
+		t.Errorf("resumed timestamp = %d, want %d", next[0].Timestamp, first[len(first)-1].Timestamp+1)
+	}
+}
+
+func TestBlockTransactions(t *testing.T) {
+	g := New(1, Config.Default().Addresses)
+	first := g.BlockTransactions(100, 40, 7)
+	next := g.BlockTransactions(10, 40, 10)
+
+	if first[0].BlockNumber != 7 || first[99].BlockNumber != 9 {
+		t.Errorf("blocks = %d..%d, want 7..9", first[0].BlockNumber, first[99].BlockNumber)
+	}
+	if first[40].TxBlockIndex != 0 || first[41].TxBlockIndex != 1 {
+		t.Errorf("txBlockIndex does not restart with the block")
+	}
+	if first[0].Timestamp != BaseTime {
+		t.Errorf("first timestamp = %d, want %d", first[0].Timestamp, BaseTime)
+	}
+	// The clock carries over, so consecutive calls stay in order
+	if next[0].Timestamp != first[99].Timestamp+1 {
+		t.Errorf("timestamp after a second call = %d, want %d", next[0].Timestamp, first[99].Timestamp+1)
+	}
+
+	seen := make(map[string]bool)
+	for _, tx := range append(first, next...) {
+		if len(tx.TransactionHash) != 66 {
+			t.Fatalf("hash %q is not 66 characters", tx.TransactionHash)
+		}
+		if seen[tx.TransactionHash] {
+			t.Fatalf("duplicate hash %s", tx.TransactionHash)
+		}
+		seen[tx.TransactionHash] = true
+	}
+}
+
+func TestStreamIndependentOfGeneratedData(t *testing.T) {
+	a, b := New(7, Config.Default().Addresses), New(7, Config.Default().Addresses)
+	b.BlockTransactions(1000, 200, 1)
+
+	sa, sb := a.Stream(3), b.Stream(3)
+	for range 100 {
+		if sa.Uint64() != sb.Uint64() {
+			t.Fatal("stream depends on what was generated before it")
+		}
+	}
+	if New(7, Config.Default().Addresses).Stream(3).Uint64() == New(7, Config.Default().Addresses).Stream(4).Uint64() {
+		t.Fatal("streams 3 and 4 start with the same value")
+	}
+}
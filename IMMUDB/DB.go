@@ -13,21 +13,51 @@ import (
 	"DBTests/Config"
 )
 
+// registry holds one *sql.DB per connection profile name,
+// the in-process server behind each embedded profile and the trusted state of each profile (see state.go)
 var (
-	db   *sql.DB
-	once sync.Once
-	err  error
+	mu       sync.Mutex
+	registry = map[string]*sql.DB{}
+	servers  = map[string]*embeddedServer{}
+	trusts   = map[string]*trust{}
 )
 
+// clientOptions builds native client options for the given profile and database
+// srv is the in-process server of an embedded profile, nil for a remote one
+func clientOptions(profile *Config.Profile, database string, srv *embeddedServer) *client.Options {
+	opts := client.DefaultOptions()
+	opts.Address = profile.Host
+	opts.Port = profile.Port
+	opts.Username = profile.User
+	opts.Password = profile.Password
+	opts.Database = database
+
+	if srv != nil {
+		opts.Address = "127.0.0.1"
+		opts.Port = srv.port
+		opts.Dir = srv.clientDir()
+		return opts
+	}
+
+	if profile.TLS.Enabled {
+		opts.MTLs = true
+		opts.MTLsOptions = client.MTLsOptions{
+			Servername:  profile.TLS.ServerName,
+			Certificate: profile.TLS.Certificate,
+			Pkey:        profile.TLS.PrivateKey,
+			ClientCAs:   profile.TLS.ClientCAs,
+		}
+		if opts.MTLsOptions.Servername == "" {
+			opts.MTLsOptions.Servername = profile.Host
+		}
+	}
+	return opts
+}
+
 // createDatabaseIfNotExists creates the database if it doesn't exist
-func createDatabaseIfNotExists(ctx context.Context, dbName string) error {
+func createDatabaseIfNotExists(ctx context.Context, profile *Config.Profile, dbName string, srv *embeddedServer) error {
 	// First connect to defaultdb to create the target database
-	defaultOpts := client.DefaultOptions()
-	defaultOpts.Address = Config.ImmuDBHost
-	defaultOpts.Port = Config.ImmuDBPort
-	defaultOpts.Username = Config.ImmuDBUser
-	defaultOpts.Password = Config.ImmuDBPassword
-	defaultOpts.Database = "defaultdb" // Connect to defaultdb first
+	defaultOpts := clientOptions(profile, "defaultdb", srv) // Connect to defaultdb first
 
 	defaultDB := stdlib.OpenDB(defaultOpts)
 	defer defaultDB.Close()
@@ -49,41 +79,127 @@ func createDatabaseIfNotExists(ctx context.Context, dbName string) error {
 	return nil
 }
 
-// ConnectDB creates and returns a singleton SQL database connection to ImmutableDB using configuration from Config package
+// ConnectDB returns the SQL database connection for the given profile (see Config.Settings.Resolve)
+// Connections are kept in a registry keyed by profile name, so every caller asking for the same
+// profile shares one *sql.DB, while different profiles can point at different immudb servers.
 // This uses the native client connection internally via stdlib
 // It will create the database if it doesn't exist
-func ConnectDB() (*sql.DB, error) {
-	once.Do(func() {
+// With the embedded backend an in-process immudb is started first (see embedded.go)
+// The server's state is then checked against the trusted state (see CheckTrustedState): a
+// server whose history went backwards or forked since the last run fails with ErrTamperAlert
+func ConnectDB(profile *Config.Profile) (*sql.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if db, ok := registry[profile.Name]; ok {
+		return db, nil
+	}
+
+	var srv *embeddedServer
+	if profile.Embedded() {
+		var err error
+		if srv, err = startEmbedded(profile); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Started embedded ImmutableDB [%s] in %s, listening on 127.0.0.1:%d\n", profile.Name, srv.dir, srv.port)
+	} else {
 		// Debugging: Print the connection details
-		fmt.Printf("Connecting to ImmutableDB at %s:%d\n", Config.ImmuDBHost, Config.ImmuDBPort)
-		fmt.Printf("Username: %s\n", Config.ImmuDBUser)
-		fmt.Printf("Database: %s\n", Config.ImmuDBDatabase)
+		fmt.Printf("Connecting to ImmutableDB [%s] at %s:%d\n", profile.Name, profile.Host, profile.Port)
+	}
+	fmt.Printf("Username: %s\n", profile.User)
+	fmt.Printf("Database: %s\n", profile.Database)
+	if profile.TLS.Enabled {
+		fmt.Println("TLS: enabled (mutual TLS)")
+	}
+
+	ctx := context.Background()
 
-		ctx := context.Background()
+	// Create database if it doesn't exist
+	fmt.Printf("Creating database '%s' if it doesn't exist...\n", profile.Database)
+	if err := createDatabaseIfNotExists(ctx, profile, profile.Database, srv); err != nil {
+		stopEmbedded(srv)
+		return nil, err
+	}
+
+	// Use stdlib to get *sql.DB which internally uses the native client
+	db := stdlib.OpenDB(clientOptions(profile, profile.Database, srv))
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		stopEmbedded(srv)
+		return nil, err
+	}
+	fmt.Println("✓ Successfully connected to ImmutableDB")
 
-		// Create database if it doesn't exist
-		fmt.Printf("Creating database '%s' if it doesn't exist...\n", Config.ImmuDBDatabase)
-		if err = createDatabaseIfNotExists(ctx, Config.ImmuDBDatabase); err != nil {
-			return
+	t, err := newTrust(ctx, profile, srv, db)
+	if err == nil {
+		var check *StateCheck
+		if check, err = t.check(ctx, db); err == nil {
+			printStateCheck(check)
 		}
+	}
+	if err != nil {
+		db.Close()
+		stopEmbedded(srv)
+		return nil, err
+	}
+
+	registry[profile.Name] = db
+	trusts[profile.Name] = t
+	if srv != nil {
+		servers[profile.Name] = srv
+	}
+	return db, nil
+}
+
+// OpenDB opens an additional connection to the profile's database that is not shared through the registry,
+// e.g. so each simulated client can own its connection. The caller must close it.
+// ConnectDB must have been called for the profile first: it creates the database and,
+// for the embedded backend, starts the server this connection talks to
+func OpenDB(profile *Config.Profile) (*sql.DB, error) {
+	mu.Lock()
+	_, connected := registry[profile.Name]
+	srv := servers[profile.Name]
+	mu.Unlock()
+	if !connected {
+		return nil, fmt.Errorf("profile %q is not connected", profile.Name)
+	}
+
+	db := stdlib.OpenDB(clientOptions(profile, profile.Database, srv))
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// stopEmbedded stops srv if the profile is embedded (srv != nil)
+func stopEmbedded(srv *embeddedServer) {
+	if srv != nil {
+		srv.stop()
+	}
+}
 
-		opts := client.DefaultOptions()
-		opts.Address = Config.ImmuDBHost
-		opts.Port = Config.ImmuDBPort
-		opts.Username = Config.ImmuDBUser
-		opts.Password = Config.ImmuDBPassword
-		opts.Database = Config.ImmuDBDatabase
-
-		// Use stdlib to get *sql.DB which internally uses the native client
-		db = stdlib.OpenDB(opts)
-
-		// Test the connection
-		if err = db.Ping(); err != nil {
-			db.Close()
-			db = nil
-			return
+// CloseAll closes every connection handed out by ConnectDB and empties the registry
+// Embedded servers are stopped after their connections are closed
+func CloseAll() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var firstErr error
+	for name, db := range registry {
+		if err := db.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close connection %q: %w", name, err)
+		}
+		delete(registry, name)
+	}
+	for name, srv := range servers {
+		if err := srv.stop(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to stop embedded server %q: %w", name, err)
 		}
-		fmt.Println("✓ Successfully connected to ImmutableDB")
-	})
-	return db, err
+		delete(servers, name)
+	}
+	clear(trusts)
+	return firstErr
 }
@@ -0,0 +1,59 @@
+package IMMUDB
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/stdlib"
+)
+
+// WithClient runs fn with the native immudb client behind one connection of db
+// Use it for calls the SQL interface doesn't offer (server info, verified reads, ...)
+func WithClient(ctx context.Context, db *sql.DB, fn func(client.ImmuClient) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("not an immudb connection: %T", driverConn)
+		}
+		return fn(c.GetImmuClient())
+	})
+}
+
+// ServerVersion returns the version reported by the immudb server behind db
+// Development builds (including the embedded server) may report an empty version
+func ServerVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version string
+	err := WithClient(ctx, db, func(c client.ImmuClient) error {
+		info, err := c.ServerInfo(ctx, &schema.ServerInfoRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to get server info: %w", err)
+		}
+		version = info.Version
+		return nil
+	})
+	return version, err
+}
+
+// CurrentTx returns the id of the latest committed transaction of the database behind db
+// Queries pinned to it (UNTIL TX) read one consistent snapshot, whatever is committed after
+func CurrentTx(ctx context.Context, db *sql.DB) (uint64, error) {
+	var tx uint64
+	err := WithClient(ctx, db, func(c client.ImmuClient) error {
+		state, err := c.CurrentState(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get current state: %w", err)
+		}
+		tx = state.TxId
+		return nil
+	})
+	return tx, err
+}
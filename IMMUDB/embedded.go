@@ -0,0 +1,120 @@
+package IMMUDB
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/logger"
+	"github.com/codenotary/immudb/pkg/server"
+
+	"DBTests/Config"
+)
+
+/*
+- Embedded backend: a full immudb server running inside this process
+- It listens on a free loopback port picked at start, and clients connect to it like to a remote
+  server, so nothing has to be installed or running; the same *sql.DB is handed back as for a remote server
+- Data lives in the profile's Dir, or in a temp dir that is removed by CloseAll. The server keeps
+  its identifier there, so trusted states (see state.go) recognise it between runs
+- Server logs go to immudb.log inside the data directory
+- The server is not run with ImmuServer.Start/Stop: Start blocks until Stop, prints its banner
+  to stdout, and installs its own SIGINT/SIGTERM handler, so Ctrl-C would stop the server under a
+  running command instead of ending the process; Stop then waits on a channel only Start reads
+- startEmbedded and stop do instead what Start and Stop do for this configuration (no metrics,
+  web, pgsql or replication): serve the gRPC server on the listener Initialize opened, run the
+  sessions guard, and on the way out stop both and close the databases. Those exported fields are
+  the server's internals, so this has to be revisited when upgrading immudb
+*/
+
+// embeddedServer is an in-process immudb started for one connection profile
+type embeddedServer struct {
+	server  *server.ImmuServer
+	port    int // the loopback port it listens on
+	dir     string
+	tempDir bool
+	logFile *os.File
+}
+
+// startEmbedded starts an in-process immudb for the profile
+func startEmbedded(profile *Config.Profile) (*embeddedServer, error) {
+	dir := profile.Dir
+	tempDir := dir == ""
+	if tempDir {
+		var err error
+		dir, err = os.MkdirTemp("", "immudb-embedded-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedded data dir: %w", err)
+		}
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create embedded data dir: %w", err)
+	}
+
+	e := &embeddedServer{dir: dir, tempDir: tempDir}
+	// Verified reads keep the client's trusted state in clientDir
+	if err := os.MkdirAll(e.clientDir(), 0o755); err != nil {
+		e.cleanup()
+		return nil, fmt.Errorf("failed to create embedded client dir: %w", err)
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, "immudb.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		e.cleanup()
+		return nil, fmt.Errorf("failed to open embedded server log: %w", err)
+	}
+	e.logFile = logFile
+
+	opts := server.DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithAddress("127.0.0.1").
+		WithPort(0).
+		// JSON keeps the server's banner off stdout; the logger below writes text all the same
+		WithLogFormat(logger.LogFormatJSON).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false)
+	srv := server.DefaultServer().WithOptions(opts).WithLogger(logger.NewSimpleLogger("immudb ", logFile)).(*server.ImmuServer)
+	if err := srv.Initialize(); err != nil {
+		e.cleanup()
+		return nil, fmt.Errorf("failed to start embedded immudb: %w", err)
+	}
+	e.server = srv
+	e.port = srv.Listener.Addr().(*net.TCPAddr).Port
+
+	// What Start does for this configuration, without its signal handler (see above)
+	go func() {
+		if err := srv.GrpcServer.Serve(srv.Listener); err != nil {
+			srv.Logger.Errorf("embedded immudb stopped serving: %v", err)
+		}
+	}()
+	if err := srv.SessManager.StartSessionsGuard(); err != nil {
+		e.stop()
+		return nil, fmt.Errorf("failed to start embedded immudb sessions: %w", err)
+	}
+	return e, nil
+}
+
+// clientDir is where clients of this server keep their local state
+func (e *embeddedServer) clientDir() string {
+	return filepath.Join(e.dir, "client")
+}
+
+// stop shuts the server down as Stop does, and removes its data if it lived in a temp dir
+func (e *embeddedServer) stop() error {
+	e.server.GrpcServer.Stop()
+	e.server.SessManager.StopSessionsGuard()
+	err := e.server.CloseDatabases()
+	e.cleanup()
+	return err
+}
+
+// cleanup closes the log and removes a temp data dir
+func (e *embeddedServer) cleanup() {
+	if e.logFile != nil {
+		e.logFile.Close()
+	}
+	if e.tempDir {
+		os.RemoveAll(e.dir)
+	}
+}
@@ -0,0 +1,425 @@
+package IMMUDB
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/state"
+
+	"DBTests/Config"
+)
+
+/*
+- Trusted state: the last database state (tx id + Alh hash) this client has verified, per server
+  and database. Verified reads (IMMUSQL Verified*) check their proofs against it and move it forward
+- It is kept in a StateStore between runs (a JSON file by default, in memory for tests), so a
+  server that rewrites or rolls back its history is caught on the next run, not only within one
+- A server is identified by the UUID immudb creates with its data directory; the address it was
+  reached at is stored too, so a different server answering at a known address is reported
+- ConnectDB calls CheckTrustedState: the first connection trusts the server's state (trust on
+  first use); later the server's state must not be older than the trusted one and must be
+  consistent with it (a dual proof), otherwise the connection fails with ErrTamperAlert
+- Forget the trusted state of a database (ForgetTrustedState) only when its server was reset on purpose
+- The file store is not locked across processes; concurrent runs against the same server each
+  advance it, which is safe because every state they write has been verified
+*/
+
+// ErrTamperAlert is returned when the server's state is not consistent with the trusted state
+var ErrTamperAlert = errors.New("TAMPER ALERT")
+
+// TrustedState is the last verified state of one database
+type TrustedState struct {
+	ServerUUID string    `json:"serverUUID"`
+	Address    string    `json:"address"`
+	Database   string    `json:"database"`
+	TxID       uint64    `json:"txId"`
+	TxHash     string    `json:"txHash"` // hex Alh of TxID
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// StateStore keeps trusted states between runs
+// Get and AtAddress return nil, nil when nothing is stored
+type StateStore interface {
+	Get(serverUUID, database string) (*TrustedState, error)
+	AtAddress(address, database string) (*TrustedState, error)
+	Set(state TrustedState) error
+	Forget(address, database string) error
+}
+
+// memoryStateStore is a StateStore that lives as long as the process
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states []TrustedState
+}
+
+// NewMemoryStateStore returns a StateStore that keeps nothing between runs
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{}
+}
+
+func (m *memoryStateStore) Get(serverUUID, database string) (*TrustedState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.find(func(s TrustedState) bool { return s.ServerUUID == serverUUID && s.Database == database }), nil
+}
+
+func (m *memoryStateStore) AtAddress(address, database string) (*TrustedState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.find(func(s TrustedState) bool { return s.Address == address && s.Database == database }), nil
+}
+
+func (m *memoryStateStore) Set(ts TrustedState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(ts)
+	return nil
+}
+
+func (m *memoryStateStore) Forget(address, database string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.forget(address, database)
+	return nil
+}
+
+// find returns a copy of the first state matching keep; m.mu must be held
+func (m *memoryStateStore) find(keep func(TrustedState) bool) *TrustedState {
+	for _, s := range m.states {
+		if keep(s) {
+			return &s
+		}
+	}
+	return nil
+}
+
+// set replaces the state of the same server and database, or adds it; m.mu must be held
+func (m *memoryStateStore) set(ts TrustedState) {
+	for i, s := range m.states {
+		if s.ServerUUID == ts.ServerUUID && s.Database == ts.Database {
+			m.states[i] = ts
+			return
+		}
+	}
+	m.states = append(m.states, ts)
+}
+
+// forget drops every state of database at address; m.mu must be held
+func (m *memoryStateStore) forget(address, database string) {
+	kept := m.states[:0]
+	for _, s := range m.states {
+		if s.Address != address || s.Database != database {
+			kept = append(kept, s)
+		}
+	}
+	m.states = kept
+}
+
+// fileStateStore is a memoryStateStore written through to a JSON file
+type fileStateStore struct {
+	memoryStateStore
+	path string
+}
+
+// NewFileStateStore returns a StateStore kept in the JSON file at path, which is created on the first Set
+func NewFileStateStore(path string) (StateStore, error) {
+	f := &fileStateStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted state: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.states); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted state %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (f *fileStateStore) Set(ts TrustedState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s := f.find(func(s TrustedState) bool { return s.ServerUUID == ts.ServerUUID && s.Database == ts.Database }); s != nil &&
+		s.TxID == ts.TxID && s.TxHash == ts.TxHash && s.Address == ts.Address {
+		return nil // unchanged: verified reads at the same state don't rewrite the file
+	}
+	f.set(ts)
+	return f.save()
+}
+
+func (f *fileStateStore) Forget(address, database string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forget(address, database)
+	return f.save()
+}
+
+// save replaces the file atomically; f.mu must be held
+func (f *fileStateStore) save() error {
+	data, err := json.MarshalIndent(f.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to write trusted state: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write trusted state: %w", err)
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// trust is the trusted state handling of one connected profile
+type trust struct {
+	mu         sync.Mutex // held while a verification reads and advances the state
+	store      StateStore
+	serverUUID string
+	address    string
+	database   string
+}
+
+// openStateStore opens the store configured by the profile
+// srv is the in-process server of an embedded profile, whose data dir holds its default file
+func openStateStore(profile *Config.Profile, srv *embeddedServer) (StateStore, error) {
+	path := profile.TrustedState
+	switch {
+	case path == Config.TrustedStateMemory:
+		return NewMemoryStateStore(), nil
+	case path != "":
+	case srv != nil:
+		path = filepath.Join(srv.clientDir(), "trusted_state.json")
+	default:
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("no default trusted state file (set trusted_state): %w", err)
+		}
+		path = filepath.Join(dir, "immudb-tests", "trusted_state.json")
+	}
+	return NewFileStateStore(path)
+}
+
+// serverAddress is how the profile reaches its server, as recorded with trusted states
+func serverAddress(profile *Config.Profile, srv *embeddedServer) string {
+	if srv != nil {
+		return "embedded:" + srv.dir
+	}
+	return fmt.Sprintf("%s:%d", profile.Host, profile.Port)
+}
+
+// newTrust opens the profile's state store and reads the server's identity through db
+func newTrust(ctx context.Context, profile *Config.Profile, srv *embeddedServer, db *sql.DB) (*trust, error) {
+	st, err := openStateStore(profile, srv)
+	if err != nil {
+		return nil, err
+	}
+	t := &trust{store: st, address: serverAddress(profile, srv), database: profile.Database}
+	err = WithClient(ctx, db, func(c client.ImmuClient) error {
+		t.serverUUID, err = state.NewUUIDProvider(c.GetServiceClient()).CurrentUUID(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the server identity: %w", err)
+	}
+	return t, nil
+}
+
+// trustedState returns the stored state of the database, nil if there is none yet
+func (t *trust) trustedState() (*TrustedState, error) {
+	return t.store.Get(t.serverUUID, t.database)
+}
+
+// save records s as the trusted state of the database
+func (t *trust) save(s *schema.ImmutableState) error {
+	return t.store.Set(TrustedState{
+		ServerUUID: t.serverUUID,
+		Address:    t.address,
+		Database:   t.database,
+		TxID:       s.TxId,
+		TxHash:     hex.EncodeToString(s.TxHash),
+		UpdatedAt:  time.Now().UTC(),
+	})
+}
+
+// stateService adapts a trust to the immudb client's state.StateService, for one client
+type stateService struct {
+	trust  *trust
+	client client.ImmuClient
+}
+
+// GetState returns the trusted state; with none stored, the server's current state is trusted on first use
+func (s *stateService) GetState(ctx context.Context, db string) (*schema.ImmutableState, error) {
+	trusted, err := s.trust.trustedState()
+	if err != nil {
+		return nil, err
+	}
+	if trusted != nil {
+		hash, err := hex.DecodeString(trusted.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted trusted state for %s: %w", db, err)
+		}
+		return &schema.ImmutableState{Db: db, TxId: trusted.TxID, TxHash: hash}, nil
+	}
+	current, err := s.client.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return current, s.trust.save(current)
+}
+
+// SetState advances the trusted state to a newly verified one; it never moves backwards
+func (s *stateService) SetState(db string, newState *schema.ImmutableState) error {
+	trusted, err := s.trust.trustedState()
+	if err != nil {
+		return err
+	}
+	if trusted != nil && newState.TxId < trusted.TxID {
+		return fmt.Errorf("%w: verified state of %s is tx %d, before the trusted tx %d", ErrTamperAlert, db, newState.TxId, trusted.TxID)
+	}
+	return s.trust.save(newState)
+}
+
+func (s *stateService) CacheLock() error {
+	s.trust.mu.Lock()
+	return nil
+}
+
+func (s *stateService) CacheUnlock() error {
+	s.trust.mu.Unlock()
+	return nil
+}
+
+// SetServerIdentity is a no-op: CheckTrustedState checks the server identity
+func (s *stateService) SetServerIdentity(string) {}
+
+// WithTrustedClient is WithClient with the client's verifications done against the profile's trusted state
+// ConnectDB must have been called for the profile first
+func WithTrustedClient(ctx context.Context, profile *Config.Profile, db *sql.DB, fn func(client.ImmuClient) error) error {
+	mu.Lock()
+	t := trusts[profile.Name]
+	mu.Unlock()
+	if t == nil {
+		return fmt.Errorf("profile %q is not connected", profile.Name)
+	}
+	return t.withClient(ctx, db, fn)
+}
+
+// withClient implements WithTrustedClient
+func (t *trust) withClient(ctx context.Context, db *sql.DB, fn func(client.ImmuClient) error) error {
+	return WithClient(ctx, db, func(c client.ImmuClient) error {
+		c.WithStateService(&stateService{trust: t, client: c})
+		return fn(c)
+	})
+}
+
+// StateCheck is the outcome of CheckTrustedState
+type StateCheck struct {
+	ServerUUID string
+	Address    string
+	Database   string
+	FirstUse   bool   // nothing was trusted yet; the server's state is now
+	TrustedTx  uint64 // trusted tx before the check (0 on first use)
+	ServerTx   uint64 // the server's current tx, now trusted
+}
+
+// CheckTrustedState verifies that the server's current state is consistent with the trusted state and trusts it
+// Returns ErrTamperAlert when the server's history went backwards or forked, or another server took its address
+func CheckTrustedState(ctx context.Context, profile *Config.Profile) (*StateCheck, error) {
+	mu.Lock()
+	t := trusts[profile.Name]
+	db := registry[profile.Name]
+	mu.Unlock()
+	if t == nil {
+		return nil, fmt.Errorf("profile %q is not connected", profile.Name)
+	}
+	return t.check(ctx, db)
+}
+
+// check implements CheckTrustedState
+func (t *trust) check(ctx context.Context, db *sql.DB) (*StateCheck, error) {
+	result := &StateCheck{ServerUUID: t.serverUUID, Address: t.address, Database: t.database}
+
+	known, err := t.store.AtAddress(t.address, t.database)
+	if err != nil {
+		return nil, err
+	}
+	if known != nil && known.ServerUUID != t.serverUUID {
+		return result, fmt.Errorf("%w: %s is now served by server %s, but tx %d of %s was trusted from server %s; "+
+			"if the server was replaced on purpose, forget its trusted state",
+			ErrTamperAlert, t.address, t.serverUUID, known.TxID, t.database, known.ServerUUID)
+	}
+	trusted, err := t.trustedState()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.withClient(ctx, db, func(c client.ImmuClient) error {
+		current, err := c.CurrentState(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get current state: %w", err)
+		}
+		result.ServerTx = current.TxId
+		if trusted == nil {
+			result.FirstUse = true
+			return t.save(current)
+		}
+		result.TrustedTx = trusted.TxID
+		switch {
+		case current.TxId < trusted.TxID:
+			return fmt.Errorf("%w: %s went back from the trusted tx %d to tx %d", ErrTamperAlert, t.database, trusted.TxID, current.TxId)
+		case current.TxId == trusted.TxID:
+			if hex.EncodeToString(current.TxHash) != trusted.TxHash {
+				return fmt.Errorf("%w: tx %d of %s has a different hash than the trusted one (forked history)", ErrTamperAlert, current.TxId, t.database)
+			}
+			return nil
+		}
+		// Proves current extends trusted, and advances the trusted state to it
+		if _, err := c.VerifiedTxByID(ctx, current.TxId); err != nil {
+			if errors.Is(err, store.ErrCorruptedData) {
+				return fmt.Errorf("%w: tx %d of %s is not consistent with the trusted tx %d (forked history)",
+					ErrTamperAlert, current.TxId, t.database, trusted.TxID)
+			}
+			return fmt.Errorf("failed to verify tx %d: %w", current.TxId, err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// ForgetTrustedState drops the trusted state of the profile's database, without connecting
+// Use it only after resetting or replacing the server on purpose
+func ForgetTrustedState(profile *Config.Profile) error {
+	var srv *embeddedServer
+	if profile.Embedded() {
+		if profile.Dir == "" {
+			return nil // a temp dir server is new every run
+		}
+		srv = &embeddedServer{dir: profile.Dir}
+	}
+	st, err := openStateStore(profile, srv)
+	if err != nil {
+		return err
+	}
+	return st.Forget(serverAddress(profile, srv), profile.Database)
+}
+
+// printStateCheck prints the outcome of the check ConnectDB runs
+func printStateCheck(check *StateCheck) {
+	if check.FirstUse {
+		fmt.Printf("✓ Trusted state: first use of %s, trusting tx %d\n", check.Database, check.ServerTx)
+		return
+	}
+	fmt.Printf("✓ Trusted state: tx %d verified against the trusted tx %d\n", check.ServerTx, check.TrustedTx)
+}
@@ -0,0 +1,169 @@
+package IMMUDB
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"DBTests/Config"
+)
+
+/*
+- Tests of the trusted state against throwaway embedded servers, one per test
+- Tampering is simulated by rewriting the stored state: a server that rolled back or forked
+  looks, to the client, exactly like a trusted state ahead of or beside the server's history
+*/
+
+// connectEmbedded connects an embedded profile on dir (a temp dir when empty) with the given trusted state store
+func connectEmbedded(t *testing.T, dir, trustedState string) (*Config.Profile, error) {
+	t.Helper()
+	settings := Config.Default()
+	settings.Backend = Config.BackendEmbedded
+	settings.Dir = dir
+	settings.Database = "statetest"
+	settings.TrustedState = trustedState
+	profile, err := settings.Resolve(settings.ActiveProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseAll() })
+	_, err = ConnectDB(profile)
+	return profile, err
+}
+
+// commitTxs commits n transactions to the profile's database
+func commitTxs(t *testing.T, profile *Config.Profile, n int) {
+	t.Helper()
+	ctx := context.Background()
+	db := registry[profile.Name]
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS txs (id INTEGER AUTO_INCREMENT, n INTEGER, PRIMARY KEY id)"); err != nil {
+		t.Fatal(err)
+	}
+	for range n {
+		if _, err := db.ExecContext(ctx, "INSERT INTO txs (n) VALUES (1)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// storedState returns the trusted state of the connected profile
+func storedState(t *testing.T, profile *Config.Profile) TrustedState {
+	t.Helper()
+	tr := trusts[profile.Name]
+	s, err := tr.trustedState()
+	if err != nil || s == nil {
+		t.Fatalf("got %v, %v; want a trusted state", s, err)
+	}
+	return *s
+}
+
+func TestTrustedStateAdvances(t *testing.T) {
+	ctx := context.Background()
+	profile, err := connectEmbedded(t, "", Config.TrustedStateMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := storedState(t, profile)
+
+	commitTxs(t, profile, 5)
+	check, err := CheckTrustedState(ctx, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.FirstUse || check.TrustedTx != first.TxID || check.ServerTx <= first.TxID {
+		t.Fatalf("got %+v, want an advance from tx %d", check, first.TxID)
+	}
+	if got := storedState(t, profile); got.TxID != check.ServerTx {
+		t.Fatalf("trusted tx %d, want %d", got.TxID, check.ServerTx)
+	}
+}
+
+func TestTrustedStateDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	profile, err := connectEmbedded(t, "", Config.TrustedStateMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTxs(t, profile, 3)
+	if _, err := CheckTrustedState(ctx, profile); err != nil {
+		t.Fatal(err)
+	}
+	current := storedState(t, profile)
+	tr := trusts[profile.Name]
+
+	for _, tc := range []struct {
+		name  string
+		state func(TrustedState) TrustedState
+	}{
+		{"rollback", func(s TrustedState) TrustedState { s.TxID += 10; return s }},
+		{"fork at the same tx", func(s TrustedState) TrustedState { s.TxHash = strings.Repeat("00", 32); return s }},
+		{"fork before", func(s TrustedState) TrustedState { s.TxID--; return s }}, // hash of another tx
+		{"other server", func(s TrustedState) TrustedState { s.ServerUUID = "another"; return s }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr.store = NewMemoryStateStore()
+			if err := tr.store.Set(tc.state(current)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := CheckTrustedState(ctx, profile); !errors.Is(err, ErrTamperAlert) {
+				t.Fatalf("got %v, want ErrTamperAlert", err)
+			}
+		})
+	}
+}
+
+func TestTrustedStateBetweenRuns(t *testing.T) {
+	dir := t.TempDir()
+	profile, err := connectEmbedded(t, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTxs(t, profile, 3)
+	if _, err := CheckTrustedState(context.Background(), profile); err != nil {
+		t.Fatal(err)
+	}
+	trusted := storedState(t, profile)
+	CloseAll()
+
+	// The same server in the next run continues from the stored state
+	if profile, err = connectEmbedded(t, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedState(t, profile); got.ServerUUID != trusted.ServerUUID || got.TxID < trusted.TxID {
+		t.Fatalf("got %+v, want to continue from %+v", got, trusted)
+	}
+	CloseAll()
+
+	// A server behind the stored state is refused
+	path := filepath.Join(dir, "client", "trusted_state.json")
+	var states []TrustedState
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &states)
+	}
+	if err != nil || len(states) != 1 {
+		t.Fatalf("got %d states, %v; want 1", len(states), err)
+	}
+	states[0].TxID += 10
+	if data, err = json.Marshal(states); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := connectEmbedded(t, dir, ""); !errors.Is(err, ErrTamperAlert) {
+		t.Fatalf("got %v, want ErrTamperAlert", err)
+	}
+
+	// Until it is forgotten
+	if err := ForgetTrustedState(profile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := connectEmbedded(t, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+}
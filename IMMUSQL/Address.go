@@ -0,0 +1,128 @@
+package IMMUSQL
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"DBTests/Config"
+)
+
+/*
+- Combined address history: every transfer an address sent, received, or both
+- Direction "both" merges the FROM and TO keyset pages on (blockNumber, txBlockIndex, id);
+  a self-transfer (fromAddr = toAddr) is found by both sides and returned once
+- Uses the same Cursor and Page as QueryRecordsByFromPage / QueryRecordsByToPage
+*/
+
+// Direction selects which transfers of an address are returned
+type Direction int
+
+const (
+	DirectionBoth Direction = iota // sent or received
+	DirectionIn                    // received (toAddr = address)
+	DirectionOut                   // sent (fromAddr = address)
+)
+
+// String returns the name used by ParseDirection
+func (d Direction) String() string {
+	switch d {
+	case DirectionIn:
+		return "in"
+	case DirectionOut:
+		return "out"
+	case DirectionBoth:
+		return "both"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// ParseDirection parses "in", "out" or "both"
+func ParseDirection(s string) (Direction, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "in":
+		return DirectionIn, nil
+	case "out":
+		return DirectionOut, nil
+	case "both", "":
+		return DirectionBoth, nil
+	}
+	return DirectionBoth, fmt.Errorf("invalid direction: %q (use in, out or both)", s)
+}
+
+// QueryRecordsByAddress retrieves one page of the transfers involving address, in chain order
+// Pass an empty cursor for the first page and page.Next for the following ones
+func (t *TableOps) QueryRecordsByAddress(ctx context.Context, address string, direction Direction, pageSize int, cursor Cursor) (*Page, error) {
+	switch direction {
+	case DirectionIn:
+		return t.queryAddressPage(ctx, "toAddr", address, pageSize, cursor)
+	case DirectionOut:
+		return t.queryAddressPage(ctx, "fromAddr", address, pageSize, cursor)
+	case DirectionBoth:
+	default:
+		return nil, fmt.Errorf("invalid direction: %v", direction)
+	}
+
+	key, err := startKey(pageSize, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first pageSize+1 rows of the union are among the first pageSize+1 rows of each side
+	sent, err := t.fetchAfter(ctx, "fromAddr", address, key, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	received, err := t.fetchAfter(ctx, "toAddr", address, key, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(mergeByKey(sent, received, pageSize+1), pageSize), nil
+}
+
+// RecordsByAddress streams every transfer involving address in chain order, fetching pageSize rows at a time
+func (t *TableOps) RecordsByAddress(ctx context.Context, address string, direction Direction, pageSize int) iter.Seq2[*Config.Transfer, error] {
+	return func(yield func(*Config.Transfer, error) bool) {
+		var cursor Cursor
+		for {
+			page, err := t.QueryRecordsByAddress(ctx, address, direction, pageSize, cursor)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, record := range page.Records {
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if page.Next == "" {
+				return
+			}
+			cursor = page.Next
+		}
+	}
+}
+
+// mergeByKey merges two sorted row lists into at most limit rows, keeping rows with the same id once
+func mergeByKey(a, b []keyedTransfer, limit int) []keyedTransfer {
+	merged := make([]keyedTransfer, 0, min(len(a)+len(b), limit))
+	i, j := 0, 0
+	for len(merged) < limit && (i < len(a) || j < len(b)) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].key.before(b[j].key)):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j].key.before(a[i].key):
+			merged = append(merged, b[j])
+			j++
+		default:
+			// Same row on both sides: a self-transfer
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	return merged
+}
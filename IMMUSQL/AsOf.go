@@ -0,0 +1,81 @@
+package IMMUSQL
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+- Time travel: immudb keeps every committed version of the table, and a period clause after the
+  table name (FROM t UNTIL TX n) reads it as it was when tx n committed
+- AsOf returns a copy of TableOps whose reads (QueryRecord*, Records*, address pages, counts,
+  head/tail, samples and statistics) all carry the clause; writes are unaffected
+- A point is a tx id (inclusive) or a commit time: the table as of the last tx committed at or
+  before it. Commit times are the server's, not the ts column of the records
+- Records committed after the point never show up, however much is ingested while the reads run
+- Verified reads and proofs always read the current table
+
+Usage:
+
+	tx, _ := ops.SnapshotTx(ctx)
+	count, err := ops.AsOf(IMMUSQL.AsOfTx(tx)).CountAllRecords(ctx)
+*/
+
+// AsOf is a point in the table's history: a tx id or a commit time; the zero value is now
+type AsOf struct {
+	Tx   uint64    // tx id, inclusive
+	Time time.Time // commit time, used when Tx is 0
+}
+
+// AsOfTx returns the point right after tx committed
+func AsOfTx(tx uint64) AsOf {
+	return AsOf{Tx: tx}
+}
+
+// AsOfTime returns the point at the commit time ts
+func AsOfTime(ts time.Time) AsOf {
+	return AsOf{Time: ts}
+}
+
+// IsZero reports whether a is now, i.e. no time travel
+func (a AsOf) IsZero() bool {
+	return a.Tx == 0 && a.Time.IsZero()
+}
+
+func (a AsOf) String() string {
+	switch {
+	case a.Tx > 0:
+		return fmt.Sprintf("tx %d", a.Tx)
+	case !a.Time.IsZero():
+		return a.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return "now"
+}
+
+// clause returns the period clause selecting the point, "" for now
+func (a AsOf) clause() string {
+	switch {
+	case a.Tx > 0:
+		return fmt.Sprintf(" UNTIL TX %d", a.Tx)
+	case !a.Time.IsZero():
+		return fmt.Sprintf(" UNTIL CAST('%s' AS TIMESTAMP)", a.Time.UTC().Format("2006-01-02 15:04:05.000000"))
+	}
+	return ""
+}
+
+// AsOf returns a copy of t whose reads see the table as of point; the zero point reads the current table
+func (t *TableOps) AsOf(point AsOf) *TableOps {
+	bound := *t
+	bound.asOf = point
+	return &bound
+}
+
+// PointInTime returns the point the reads of t see
+func (t *TableOps) PointInTime() AsOf {
+	return t.asOf
+}
+
+// from returns the table reference of read queries, with the period clause of t's point
+func (t *TableOps) from() string {
+	return t.table + t.asOf.clause()
+}
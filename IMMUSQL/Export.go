@@ -0,0 +1,117 @@
+package IMMUSQL
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+)
+
+/*
+- Export: the whole table, or a block/time range, streamed in id (insertion) order
+- Every page is read UNTIL TX of one pinned transaction, so the export is a consistent snapshot:
+  records committed while it runs are left out, however long it takes
+- Pages are fetched by keyset on id (id > last id), so each page costs the same however deep
+  the export is, and memory stays at one page
+
+Usage:
+
+	tx, _ := ops.SnapshotTx(ctx)
+	for record, err := range ops.ExportRecords(ctx, IMMUSQL.ExportRange{}, tx, 1000) {
+		...
+	}
+*/
+
+// ExportRange selects the records of an export; zero fields are unbounded
+type ExportRange struct {
+	FromBlock int       // first block, inclusive
+	ToBlock   *int      // last block, inclusive (nil = no upper bound)
+	Start     time.Time // earliest ts, inclusive
+	End       time.Time // latest ts, inclusive
+}
+
+// ExportedRecord is a transfer with its primary key
+type ExportedRecord struct {
+	ID int64 `json:"id"`
+	Config.Transfer
+}
+
+// SnapshotTx returns the latest committed transaction, to pin an export to
+func (t *TableOps) SnapshotTx(ctx context.Context) (uint64, error) {
+	return IMMUDB.CurrentTx(ctx, t.DB)
+}
+
+// ExportRecords streams the records of r as of transaction tx (inclusive), in id order, pageSize per query
+// A failure is yielded once as (nil, err) and ends the stream
+func (t *TableOps) ExportRecords(ctx context.Context, r ExportRange, tx uint64, pageSize int) iter.Seq2[*ExportedRecord, error] {
+	where := []string{"id > ?"}
+	var bounds []any
+	if r.FromBlock > 0 {
+		where = append(where, "blockNumber >= ?")
+		bounds = append(bounds, r.FromBlock)
+	}
+	if r.ToBlock != nil {
+		where = append(where, "blockNumber <= ?")
+		bounds = append(bounds, *r.ToBlock)
+	}
+	if !r.Start.IsZero() {
+		where = append(where, "ts >= ?")
+		bounds = append(bounds, r.Start.UTC())
+	}
+	if !r.End.IsZero() {
+		where = append(where, "ts <= ?")
+		bounds = append(bounds, r.End.UTC())
+	}
+	exportPageSQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s UNTIL TX ? WHERE %s ORDER BY id LIMIT ?",
+		transferColumns, t.table, strings.Join(where, " AND "),
+	)
+
+	return func(yield func(*ExportedRecord, error) bool) {
+		var after int64
+		for {
+			args := append([]any{tx, after}, bounds...)
+			page, err := t.exportPage(ctx, exportPageSQL, append(args, pageSize)...)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, record := range page {
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if len(page) < pageSize {
+				return
+			}
+			after = page[len(page)-1].ID
+		}
+	}
+}
+
+// exportPage runs one page query of ExportRecords
+func (t *TableOps) exportPage(ctx context.Context, querySQL string, args ...any) ([]*ExportedRecord, error) {
+	rows, err := t.DB.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query export page: %w", err)
+	}
+	defer rows.Close()
+
+	var page []*ExportedRecord
+	for rows.Next() {
+		var id int64
+		record, err := scanTransfer(rows, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		page = append(page, &ExportedRecord{ID: id, Transfer: *record})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return page, nil
+}
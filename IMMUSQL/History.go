@@ -0,0 +1,358 @@
+package IMMUSQL
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+)
+
+/*
+- Revision history: immudb keeps every version of a row; HistoryOfRecord lists them for a transfer
+  hash, with the tx that wrote each one and its commit time, what changed from the previous one,
+  and whether the row was deleted, i.e. whether the transfer was ever altered after its insert
+- The rows are the current ones with the hash, found through the transactionHash index. With removed,
+  also the rows that no longer carry it: a deleted one, one inserted again (with a new id) after a
+  delete, or one whose hash was changed by an update. The index only covers the current rows, so
+  those are found by a scan of the table's whole history
+- SQL lists a row's revisions (HISTORY OF) but not their txs. The txs are found by bisection with
+  period reads of the row (SINCE TX a UNTIL TX b), then each revision is read at its tx with its
+  proofs and verified like a verified read: included in its tx, that tx consistent with the state
+  the history is read at, and that state with the client's trusted state. The verified entry gives
+  the commit time and the deletion marker, and must hold the values HISTORY OF listed
+- A deleted row is invisible as of any tx outside its lifetime. Its insert is where "a row with this
+  id or a larger one is visible" turns true, since ids are AUTO_INCREMENT (a larger id is inserted
+  no earlier) and a deleted id is never inserted again; that holds while a later row still exists
+- Cost, in round trips: one index lookup to find the rows (the history scan with removed); then per
+  row one HISTORY OF read by its primary key, about log2(txs) period reads for each revision,
+  2·log2(txs) more for a deleted row, and one VerifiableSQLGet per revision. Only a
+  deleted row whose later rows were all deleted too is searched tx by tx, from the insert of the
+  closest earlier row still there
+- The history is read as of the state current when the call starts
+*/
+
+// RecordHistory is the revision history of one row that carried a transfer hash
+type RecordHistory struct {
+	ID        int64            `json:"id"`
+	Revisions []RecordRevision `json:"revisions"` // oldest first; the first is the insert
+	Deleted   bool             `json:"deleted"`   // the last revision deleted the row
+}
+
+// RecordRevision is one version of a row
+type RecordRevision struct {
+	Revision    int             `json:"revision"` // 1 for the insert
+	TxID        uint64          `json:"txId"`
+	CommittedAt time.Time       `json:"committedAt"`
+	Deleted     bool            `json:"deleted"`           // the revision deleted the row; Transfer holds the values deleted
+	Transfer    Config.Transfer `json:"transfer"`          // the row's values
+	Changes     []FieldChange   `json:"changes,omitempty"` // from the previous revision
+}
+
+// FieldChange is a column whose value differs between two revisions
+type FieldChange struct {
+	Column string `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// Altered reports whether the row was updated or deleted after its insert
+func (h *RecordHistory) Altered() bool {
+	return h.Deleted || slices.ContainsFunc(h.Revisions, func(r RecordRevision) bool { return len(r.Changes) > 0 })
+}
+
+// HistoryOfRecord returns the verified revision history of the rows with the transfer hash, in id order
+// removed also looks for the rows that no longer have it, which scans the table's whole history
+// Returns nil, nil when no row was found, and ErrVerification when a revision doesn't match its proofs
+func (t *TableOps) HistoryOfRecord(ctx context.Context, transactionHash string, removed bool) ([]*RecordHistory, error) {
+	var histories []*RecordHistory
+	err := IMMUDB.WithTrustedClient(ctx, t.Profile, t.DB, func(c client.ImmuClient) error {
+		tx, alh, err := verifiedState(ctx, c)
+		if err != nil {
+			return err
+		}
+		h := &rowHistory{TableOps: t, c: c, tx: tx, alh: alh}
+
+		query := fmt.Sprintf("SELECT id FROM %s UNTIL TX %d WHERE transactionHash = @hash", t.table, tx)
+		if removed {
+			query = fmt.Sprintf("SELECT id FROM (HISTORY OF %s) WHERE transactionHash = @hash", t.table)
+		}
+		result, err := c.SQLQuery(ctx, query, map[string]interface{}{"hash": transactionHash}, false)
+		if err != nil {
+			return fmt.Errorf("failed to query records: %w", err)
+		}
+		var ids []int64
+		for _, row := range result.Rows {
+			ids = append(ids, row.Values[0].GetN())
+		}
+		slices.Sort(ids)
+		for _, id := range slices.Compact(ids) {
+			history, err := h.of(ctx, id)
+			if err != nil {
+				return fmt.Errorf("record id %d: %w", id, err)
+			}
+			if len(history.Revisions) > 0 {
+				histories = append(histories, history)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// rowHistory reads row histories on one client, as of the verified state at tx
+type rowHistory struct {
+	*TableOps
+	c   client.ImmuClient
+	tx  uint64
+	alh [sha256.Size]byte // accumulated hash of tx
+}
+
+// of returns the history of the row with the id, without the revisions committed after h.tx
+func (h *rowHistory) of(ctx context.Context, id int64) (*RecordHistory, error) {
+	result, err := h.c.SQLQuery(ctx,
+		fmt.Sprintf("SELECT _rev, id, %s FROM (HISTORY OF %s) WHERE id = @id", transferColumns, h.table),
+		map[string]interface{}{"id": id}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	rows := result.Rows
+	slices.SortFunc(rows, func(a, b *schema.Row) int { return cmp.Compare(a.Values[0].GetN(), b.Values[0].GetN()) })
+
+	txs, deleted, err := h.revisionTxs(ctx, id, len(rows))
+	if err != nil {
+		return nil, err
+	}
+	history := &RecordHistory{ID: id, Deleted: deleted}
+	for i, tx := range txs {
+		revision, err := h.revisionAt(ctx, id, tx, rows[i])
+		if err != nil {
+			return nil, err
+		}
+		if revision.Deleted != (deleted && i == len(txs)-1) {
+			return nil, fmt.Errorf("revision %d at tx %d: unexpected deletion marker", revision.Revision, tx)
+		}
+		if i > 0 && !revision.Deleted {
+			revision.Changes = diffTransfer(history.Revisions[i-1].Transfer, revision.Transfer)
+		}
+		history.Revisions = append(history.Revisions, *revision)
+	}
+	return history, nil
+}
+
+// revisionTxs returns the txs that wrote the row's revisions up to h.tx, oldest first, and whether the last one deleted it
+// revisions is the number of revisions HISTORY OF lists, which may include some after h.tx
+func (h *rowHistory) revisionTxs(ctx context.Context, id int64, revisions int) ([]uint64, bool, error) {
+	visible := func(tx uint64) (bool, error) { return h.present(ctx, id, 0, tx) }
+	live, err := visible(h.tx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// From first to last the row is visible, and every write in between is an update
+	var first, last, deletedAt uint64
+	var found bool
+	if live {
+		last = h.tx
+		first, found, err = h.search(1, last, visible)
+	} else if first, found, err = h.insertOfDeleted(ctx, id); found && err == nil {
+		deletedAt, found, err = h.search(first+1, h.tx, func(tx uint64) (bool, error) {
+			ok, err := visible(tx)
+			return !ok, err
+		})
+		last = deletedAt - 1
+	}
+	if err != nil || !found {
+		// Not found: the row was inserted after h.tx
+		return nil, false, err
+	}
+
+	txs := []uint64{first}
+	for len(txs) < revisions && txs[len(txs)-1] < last {
+		since := txs[len(txs)-1] + 1
+		tx, found, err := h.search(since, last, func(tx uint64) (bool, error) { return h.present(ctx, id, since, tx) })
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			break
+		}
+		txs = append(txs, tx)
+	}
+	if !live {
+		txs = append(txs, deletedAt)
+	}
+	if len(txs) > revisions {
+		return nil, false, fmt.Errorf("found %d revisions, HISTORY OF lists %d", len(txs), revisions)
+	}
+	return txs, !live, nil
+}
+
+// insertOfDeleted returns the tx that inserted the row with the id, deleted as of h.tx
+// found is false when the row was inserted after h.tx
+func (h *rowHistory) insertOfDeleted(ctx context.Context, id int64) (tx uint64, found bool, err error) {
+	visible := func(tx uint64) (bool, error) { return h.present(ctx, id, 0, tx) }
+	inserted := func(tx uint64) (bool, error) {
+		ok, err := visible(tx)
+		if err != nil || !ok || tx == 1 {
+			return ok, err
+		}
+		before, err := visible(tx - 1)
+		return !before, err
+	}
+
+	// With a later row still there, "this row or a later one is visible" is false before the insert and true from it on
+	later := func(tx uint64) (bool, error) { return h.presentFrom(ctx, id, tx) }
+	if ok, err := later(h.tx); err != nil || ok {
+		if err != nil {
+			return 0, false, err
+		}
+		tx, found, err := h.search(1, h.tx, later)
+		if err != nil || !found {
+			return 0, false, err
+		}
+		// Otherwise this row and the ones up to the next left were all deleted before that one was inserted
+		if ok, err := inserted(tx); err != nil || ok {
+			return tx, ok, err
+		}
+	}
+
+	// Tx by tx, from the insert of the closest earlier row still there
+	from := uint64(1)
+	result, err := h.c.SQLQuery(ctx,
+		fmt.Sprintf("SELECT id FROM %s UNTIL TX %d WHERE id < @id ORDER BY id DESC LIMIT 1", h.table, h.tx),
+		map[string]interface{}{"id": id}, false)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query the previous record: %w", err)
+	}
+	if len(result.Rows) > 0 {
+		prev := result.Rows[0].Values[0].GetN()
+		from, _, err = h.search(1, h.tx, func(tx uint64) (bool, error) { return h.present(ctx, prev, 0, tx) })
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	for tx := from; tx <= h.tx; tx++ {
+		ok, err := h.present(ctx, id, tx, tx)
+		if err != nil || ok {
+			return tx, ok, err
+		}
+	}
+	return 0, false, nil
+}
+
+// presentFrom reports whether a row with the id or a larger one is visible as of tx
+func (h *rowHistory) presentFrom(ctx context.Context, id int64, tx uint64) (bool, error) {
+	result, err := h.c.SQLQuery(ctx,
+		fmt.Sprintf("SELECT id FROM %s UNTIL TX %d WHERE id >= @id LIMIT 1", h.table, tx),
+		map[string]interface{}{"id": id}, false)
+	if err != nil {
+		return false, fmt.Errorf("failed to query records as of tx %d: %w", tx, err)
+	}
+	return len(result.Rows) > 0, nil
+}
+
+// present reports whether the row with the id is visible as of until, written at since or later when since > 0
+func (h *rowHistory) present(ctx context.Context, id int64, since, until uint64) (bool, error) {
+	period := fmt.Sprintf("UNTIL TX %d", until)
+	if since > 0 {
+		period = fmt.Sprintf("SINCE TX %d %s", since, period)
+	}
+	result, err := h.c.SQLQuery(ctx,
+		fmt.Sprintf("SELECT id FROM %s %s WHERE id = @id", h.table, period),
+		map[string]interface{}{"id": id}, false)
+	if err != nil {
+		return false, fmt.Errorf("failed to query record as of tx %d: %w", until, err)
+	}
+	return len(result.Rows) > 0, nil
+}
+
+// search returns the first tx in [lo, hi] for which pred holds, pred being false before it and true from it on
+func (h *rowHistory) search(lo, hi uint64, pred func(uint64) (bool, error)) (uint64, bool, error) {
+	end := hi + 1
+	for lo < end {
+		mid := lo + (end-lo)/2
+		ok, err := pred(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			end = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, lo <= hi, nil
+}
+
+// revisionAt reads the row with the id as written at tx, verifies it, and checks it against row, its revision as HISTORY OF lists it
+func (h *rowHistory) revisionAt(ctx context.Context, id int64, tx uint64, row *schema.Row) (*RecordRevision, error) {
+	entry, err := provenEntry(ctx, h.c, h.table, id, tx, h.tx)
+	if err != nil {
+		return nil, fmt.Errorf("revision at tx %d: %w", tx, err)
+	}
+	if entry.SqlEntry.Tx != tx {
+		return nil, fmt.Errorf("no row written at tx %d", tx)
+	}
+	header, stateTx, stateAlh, err := verifyEntry(entry, id)
+	if err == nil && (stateTx != h.tx || stateAlh != h.alh) {
+		err = fmt.Errorf("%w: proven against tx %d, not the verified state at tx %d", ErrVerification, stateTx, h.tx)
+	}
+	revision := int(row.Values[0].GetN())
+	values := &schema.Row{Columns: row.Columns[1:], Values: row.Values[1:]}
+	if err == nil {
+		var stored map[uint32]*schema.SQLValue
+		if stored, err = decodeStoredRow(entry.SqlEntry.Value, entry.ColTypesById, entry.MaxColId); err == nil {
+			err = matchStoredRow(values, stored, entry.ColIdsByName)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("revision %d at tx %d: %w", revision, tx, err)
+	}
+	_, transfer, err := rowTransfer(values)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordRevision{
+		Revision:    revision,
+		TxID:        tx,
+		CommittedAt: time.Unix(header.Ts, 0).UTC(),
+		Deleted:     entry.SqlEntry.Metadata.GetDeleted(),
+		Transfer:    *transfer,
+	}, nil
+}
+
+// diffTransfer returns the columns whose values differ from old to new, in column order
+func diffTransfer(old, new Config.Transfer) []FieldChange {
+	ts := func(s int64) string { return time.Unix(s, 0).UTC().Format(time.RFC3339) }
+	columns := []struct {
+		name     string
+		old, new string
+	}{
+		{"transactionHash", old.TransactionHash, new.TransactionHash},
+		{"fromAddr", old.From, new.From},
+		{"toAddr", old.To, new.To},
+		{"blockNumber", strconv.Itoa(old.BlockNumber), strconv.Itoa(new.BlockNumber)},
+		{"blockHash", old.BlockHash, new.BlockHash},
+		{"txBlockIndex", strconv.Itoa(old.TxBlockIndex), strconv.Itoa(new.TxBlockIndex)},
+		{"ts", ts(old.Timestamp), ts(new.Timestamp)},
+	}
+	var changes []FieldChange
+	for _, c := range columns {
+		if c.old != c.new {
+			changes = append(changes, FieldChange{Column: c.name, Old: c.old, New: c.new})
+		}
+	}
+	return changes
+}
@@ -2,19 +2,36 @@ package IMMUSQL
 
 import (
     "context"
+    "database/sql"
     "fmt"
     "time"
 )
 
 // CompareOrderByIndexTest runs each diagnostic query twice (with and without ORDER BY on the filtered column),
 // measures average execution time over several iterations and prints a comparison for each index-tested column.
-func (t *TableOps) CompareOrderByIndexTest(ctx context.Context, tableName string) error {
+func (t *TableOps) CompareOrderByIndexTest(ctx context.Context) error {
     fmt.Println("\n=== Compare ORDER BY effect on index usage ===")
 
-    // diagnostic queries and test values (reuse values from TestIndexPerformance)
-    testHash := "0x90b01ec0ed76601314559f16eefb873bbf1a0a145f805358d0c377944593403c"
-    testFrom := "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0"
-    testTo := testFrom
+    // diagnostic queries and test values, taken from the first record so they exist in this table
+    head, _, err := t.GetHeadRecord(ctx)
+    if err != nil {
+        return err
+    }
+    if head == nil {
+        return fmt.Errorf("table %s is empty; insert transactions first", t.table)
+    }
+    testHash := head.TransactionHash
+    testFrom := head.From
+    testTo := head.To
+    if testTo == "" {
+        // the head is a contract creation (NULL toAddr): take the first record that has a recipient
+        err := t.DB.QueryRowContext(ctx,
+            fmt.Sprintf("SELECT toAddr FROM %s WHERE toAddr IS NOT NULL ORDER BY id LIMIT 1", t.table),
+        ).Scan(&testTo)
+        if err != nil && err != sql.ErrNoRows {
+            return fmt.Errorf("failed to find a record with a recipient: %w", err)
+        }
+    }
     testBlockNumber := 51 // use an unlikely block number to measure lookup time (works for COUNT)
 
     // each item: name, sql without ORDER BY, sql with ORDER BY, arg
@@ -27,26 +44,26 @@ func (t *TableOps) CompareOrderByIndexTest(ctx context.Context, tableName string
     qs := []qitem{
         {
             name:    "transactionHash",
-            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ?", tableName),
-            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ? ORDER BY transactionHash", tableName),
+            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ?", t.table),
+            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ? ORDER BY transactionHash", t.table),
             arg:     testHash,
         },
         {
             name:    "fromAddr",
-            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ?", tableName),
-            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ? ORDER BY fromAddr", tableName),
+            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ?", t.table),
+            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ? ORDER BY fromAddr", t.table),
             arg:     testFrom,
         },
         {
             name:    "toAddr",
-            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE toAddr = ?", tableName),
-            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE toAddr = ? ORDER BY toAddr", tableName),
+            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE toAddr = ?", t.table),
+            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE toAddr = ? ORDER BY toAddr", t.table),
             arg:     testTo,
         },
         {
             name:    "blockNumber",
-            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE blockNumber = ?", tableName),
-            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE blockNumber = ? ORDER BY blockNumber", tableName),
+            noOrder: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE blockNumber = ?", t.table),
+            withOrd: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE blockNumber = ? ORDER BY blockNumber", t.table),
             arg:     testBlockNumber,
         },
     }
@@ -54,6 +71,10 @@ func (t *TableOps) CompareOrderByIndexTest(ctx context.Context, tableName string
     iterations := 5
 
     for _, q := range qs {
+        if q.arg == "" {
+            fmt.Printf("\nSkipping %s: no record in the table has a value for it\n", q.name)
+            continue
+        }
         var totalNo time.Duration
         var totalWith time.Duration
         var lastCountNo int
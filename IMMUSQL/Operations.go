@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"iter"
+	"math/rand/v2"
 	"strings"
 	"time"
 
@@ -40,24 +42,103 @@ Performance expectations:
 - Without indexes: 1-3s for full table scans
 */
 
+// TableOps runs the transfer table operations against one table
+// GetTableOps binds it to Settings.Table; use Table to get a handle on another table
+// sharing the same connection (e.g. an unindexed copy for benchmarking)
 type TableOps struct {
-	DB *sql.DB
+	DB       *sql.DB
+	Settings *Config.Settings
+	Profile  *Config.Profile
+
+	table            string
+	serverTimestamps bool
+	batchSize        int
+	asOf             AsOf // point in history the reads see (see AsOf.go)
 }
 
+// DefaultBatchSize is the number of records InsertRecords sends per INSERT unless WithBatchSize overrides it
+const DefaultBatchSize = 200
+
 // GetTableOps creates and returns a TableOps instance with connected ImmutableDB database
-func GetTableOps() *TableOps {
-	db, err := IMMUDB.ConnectDB()
+// Connection details come from the active profile in settings (see Config.Load)
+func GetTableOps(settings *Config.Settings) *TableOps {
+	return GetTableOpsForProfile(settings, settings.ActiveProfile)
+}
+
+// GetTableOpsForProfile creates and returns a TableOps instance connected through the named profile
+// Each profile gets its own connection from the IMMUDB registry
+func GetTableOpsForProfile(settings *Config.Settings, profileName string) *TableOps {
+	profile, err := settings.Resolve(profileName)
+	if err != nil {
+		panic(err)
+	}
+	db, err := IMMUDB.ConnectDB(profile)
 	if err != nil {
 		panic(err)
 	}
 	return &TableOps{
-		DB: db,
+		DB:       db,
+		Settings: settings,
+		Profile:  profile,
+		table:    settings.Table,
+
+		serverTimestamps: settings.ServerTimestamps,
+	}
+}
+
+// Table returns a copy of t bound to the named table, sharing the same connection
+// The name is spliced into SQL, so it must be a plain identifier (see Config.ValidIdentifier)
+func (t *TableOps) Table(name string) *TableOps {
+	if !Config.ValidIdentifier(name) {
+		panic(fmt.Sprintf("invalid table name: %q", name))
 	}
+	bound := *t
+	bound.table = name
+	return &bound
+}
+
+// TableName returns the name of the table t is bound to
+func (t *TableOps) TableName() string {
+	return t.table
+}
+
+// WithServerTimestamps returns a copy of t that writes the server's NOW() into ts on insert
+// instead of the record's Timestamp. Off by default, so block times survive the round trip
+func (t *TableOps) WithServerTimestamps(enabled bool) *TableOps {
+	bound := *t
+	bound.serverTimestamps = enabled
+	return &bound
+}
+
+// WithBatchSize returns a copy of t that InsertRecords in batches of size records
+// size <= 0 restores DefaultBatchSize
+func (t *TableOps) WithBatchSize(size int) *TableOps {
+	bound := *t
+	bound.batchSize = size
+	return &bound
+}
+
+// WithDB returns a copy of t that runs its queries on db instead of the shared profile connection
+func (t *TableOps) WithDB(db *sql.DB) *TableOps {
+	bound := *t
+	bound.DB = db
+	return &bound
+}
+
+// insertPlaceholders returns the VALUES tuple for one record and appends its arguments to args
+// ts is bound from record.Timestamp unless server timestamps are enabled; an empty To is bound as NULL
+func (t *TableOps) insertPlaceholders(record Config.Transfer, args []interface{}) (string, []interface{}) {
+	to := sql.NullString{String: record.To, Valid: record.To != ""}
+	args = append(args, record.TransactionHash, record.From, to, record.BlockNumber, record.BlockHash, record.TxBlockIndex)
+	if t.serverTimestamps {
+		return "(?, ?, ?, ?, ?, ?, NOW())", args
+	}
+	return "(?, ?, ?, ?, ?, ?, ?)", append(args, time.Unix(record.Timestamp, 0).UTC())
 }
 
 // CreateTableWithoutIndexes creates a SQL table in ImmutableDB WITHOUT indexes
 // This is used for benchmarking to compare performance with vs without indexes
-func (t *TableOps) CreateTableWithoutIndexes(ctx context.Context, tableName string) error {
+func (t *TableOps) CreateTableWithoutIndexes(ctx context.Context) error {
 	createTableSQL := fmt.Sprintf(`
 	CREATE TABLE %s (
 		id INTEGER AUTO_INCREMENT,
@@ -70,7 +151,7 @@ func (t *TableOps) CreateTableWithoutIndexes(ctx context.Context, tableName stri
 		ts TIMESTAMP NOT NULL,
 		PRIMARY KEY (id)
 	)
-	`, tableName)
+	`, t.table)
 
 	_, err := t.DB.ExecContext(ctx, createTableSQL)
 	if err != nil {
@@ -90,7 +171,7 @@ func (t *TableOps) CreateTableWithoutIndexes(ctx context.Context, tableName stri
 }
 
 // CreateTable creates a SQL table in ImmutableDB
-func (t *TableOps) CreateTable(ctx context.Context, tableName string) error {
+func (t *TableOps) CreateTable(ctx context.Context) error {
 	createTableSQL := fmt.Sprintf(`
 	CREATE TABLE %s (
 		id INTEGER AUTO_INCREMENT,
@@ -103,7 +184,7 @@ func (t *TableOps) CreateTable(ctx context.Context, tableName string) error {
 		ts TIMESTAMP NOT NULL,
 		PRIMARY KEY (id)
 	)
-	`, tableName)
+	`, t.table)
 
 	_, err := t.DB.ExecContext(ctx, createTableSQL)
 	if err != nil {
@@ -128,7 +209,7 @@ func (t *TableOps) CreateTable(ctx context.Context, tableName string) error {
 	if countErr == nil && rowCount > 0 {
 		fmt.Printf("\n⚠ WARNING: Table has %d records. Indexes can only be created on empty tables!\n", rowCount)
 		fmt.Println("   Indexes will NOT be created. To create indexes:")
-		fmt.Println("   1. Drop the table (DROP TABLE historytable)")
+		fmt.Printf("   1. Drop the table (DROP TABLE %s)\n", t.table)
 		fmt.Println("   2. Recreate table (this will create indexes on empty table)")
 		fmt.Println("   3. Then insert data")
 		fmt.Println()
@@ -149,10 +230,13 @@ func (t *TableOps) CreateTable(ctx context.Context, tableName string) error {
 		name string
 		sql  string
 	}{
-		{"transactionHash", fmt.Sprintf(`CREATE INDEX ON %s(transactionHash)`, tableName)},
-		{"fromAddr", fmt.Sprintf(`CREATE INDEX ON %s(fromAddr)`, tableName)},
-		{"toAddr", fmt.Sprintf(`CREATE INDEX ON %s(toAddr)`, tableName)},
-		{"blockNumber", fmt.Sprintf(`CREATE INDEX ON %s(blockNumber)`, tableName)},
+		{"transactionHash", fmt.Sprintf(`CREATE INDEX ON %s(transactionHash)`, t.table)},
+		{"fromAddr", fmt.Sprintf(`CREATE INDEX ON %s(fromAddr)`, t.table)},
+		{"toAddr", fmt.Sprintf(`CREATE INDEX ON %s(toAddr)`, t.table)},
+		{"blockNumber", fmt.Sprintf(`CREATE INDEX ON %s(blockNumber)`, t.table)},
+		// Composite indexes serve the paginated address history (see Pagination.go)
+		{"fromAddr, blockNumber, txBlockIndex", fmt.Sprintf(`CREATE INDEX ON %s(fromAddr, blockNumber, txBlockIndex)`, t.table)},
+		{"toAddr, blockNumber, txBlockIndex", fmt.Sprintf(`CREATE INDEX ON %s(toAddr, blockNumber, txBlockIndex)`, t.table)},
 	}
 
 	for _, idx := range indexSQLs {
@@ -203,12 +287,12 @@ func (t *TableOps) CreateTable(ctx context.Context, tableName string) error {
 // RecreateTableWithIndexes drops the existing table and recreates it with indexes
 // This is necessary because ImmutableDB only allows indexes on empty tables
 // WARNING: This will delete all data in the table!
-func (t *TableOps) RecreateTableWithIndexes(ctx context.Context, tableName string) error {
+func (t *TableOps) RecreateTableWithIndexes(ctx context.Context) error {
 	fmt.Println("⚠ WARNING: Dropping existing table to recreate with indexes...")
 	fmt.Println("   All data will be lost!")
 
 	// Drop table
-	dropSQL := fmt.Sprintf("DROP TABLE %s", tableName)
+	dropSQL := fmt.Sprintf("DROP TABLE %s", t.table)
 	_, err := t.DB.ExecContext(ctx, dropSQL)
 	if err != nil {
 		errMsg := strings.ToLower(err.Error())
@@ -221,12 +305,12 @@ func (t *TableOps) RecreateTableWithIndexes(ctx context.Context, tableName strin
 	}
 
 	// Recreate table with indexes
-	return t.CreateTable(ctx, tableName)
+	return t.CreateTable(ctx)
 }
 
 // DropTable drops the table (used for clean benchmarking)
-func (t *TableOps) DropTable(ctx context.Context, tableName string) error {
-	dropSQL := fmt.Sprintf("DROP TABLE %s", tableName)
+func (t *TableOps) DropTable(ctx context.Context) error {
+	dropSQL := fmt.Sprintf("DROP TABLE %s", t.table)
 	_, err := t.DB.ExecContext(ctx, dropSQL)
 	if err != nil {
 		errMsg := strings.ToLower(err.Error())
@@ -239,7 +323,7 @@ func (t *TableOps) DropTable(ctx context.Context, tableName string) error {
 }
 
 // TestIndexPerformance runs a quick test to verify if indexes are actually working
-func (t *TableOps) TestIndexPerformance(ctx context.Context, tableName string) error {
+func (t *TableOps) TestIndexPerformance(ctx context.Context) error {
 	fmt.Println("\n=== Testing Index Performance ===")
 
 	// Get total record count first
@@ -258,7 +342,7 @@ func (t *TableOps) TestIndexPerformance(ctx context.Context, tableName string) e
 
 	// Test 1: Hash query (should use index)
 	fmt.Println("\n1. Testing hash query (should use index on transactionHash)...")
-	testHashSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ?", tableName)
+	testHashSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE transactionHash = ?", t.table)
 
 	// Use a hash that likely doesn't exist to test lookup speed
 	testHash := "0x0000000000000000000000000000000000000000000000000000000000000000"
@@ -281,8 +365,13 @@ func (t *TableOps) TestIndexPerformance(ctx context.Context, tableName string) e
 
 	// Test 2: FROM address query (should use index)
 	fmt.Println("\n2. Testing FROM address query (should use index on fromAddr)...")
-	testFromSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ?", tableName)
-	testAddr := "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0"
+	testFromSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE fromAddr = ?", t.table)
+	// An address taken from the first record, so it exists whatever distribution wrote the table
+	head, _, err := t.GetHeadRecord(ctx)
+	if err != nil {
+		return err
+	}
+	testAddr := head.From
 
 	start = time.Now()
 	err = t.DB.QueryRowContext(ctx, testFromSQL, testAddr).Scan(&count)
@@ -323,11 +412,12 @@ func (t *TableOps) TestIndexPerformance(ctx context.Context, tableName string) e
 
 // InsertRecord inserts a transfer record using ImmutableDB SQL
 func (t *TableOps) InsertRecord(ctx context.Context, record Config.Transfer) error {
+	placeholders, args := t.insertPlaceholders(record, nil)
 	insertRecordSQL := fmt.Sprintf(
-		"INSERT INTO %s (transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts) VALUES (?, ?, ?, ?, ?, ?, NOW())",
-		Config.ImmuDBTable,
+		"INSERT INTO %s (transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts) VALUES %s",
+		t.table, placeholders,
 	)
-	_, err := t.DB.ExecContext(ctx, insertRecordSQL, record.TransactionHash, record.From, record.To, record.BlockNumber, record.BlockHash, record.TxBlockIndex)
+	_, err := t.DB.ExecContext(ctx, insertRecordSQL, args...)
 	return err
 }
 
@@ -339,8 +429,11 @@ func (t *TableOps) InsertRecords(ctx context.Context, records []Config.Transfer)
 	}
 
 	// ImmutableDB has a limit on entries per transaction, so we batch in chunks
-	// Using 1000 records per batch as a safe limit
-	batchSize := 200
+	// Using DefaultBatchSize records per batch as a safe limit
+	batchSize := DefaultBatchSize
+	if t.batchSize > 0 {
+		batchSize = t.batchSize
+	}
 	totalRecords := len(records)
 
 	for i := 0; i < totalRecords; i += batchSize {
@@ -368,17 +461,17 @@ func (t *TableOps) insertBatch(ctx context.Context, records []Config.Transfer) e
 	// Build batch INSERT statement with multiple VALUES clauses
 	insertRecordsSQL := fmt.Sprintf(
 		"INSERT INTO %s (transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts) VALUES ",
-		Config.ImmuDBTable,
+		t.table,
 	)
 
 	// Build VALUES placeholders and arguments
-	// Using NOW() for ts column instead of parameterized value
 	values := make([]string, 0, len(records))
-	args := make([]interface{}, 0, len(records)*6) // 6 fields per record (ts uses NOW())
+	args := make([]interface{}, 0, len(records)*7) // 7 fields per record (6 with server timestamps)
 
 	for _, record := range records {
-		values = append(values, "(?, ?, ?, ?, ?, ?, NOW())")
-		args = append(args, record.TransactionHash, record.From, record.To, record.BlockNumber, record.BlockHash, record.TxBlockIndex)
+		var placeholders string
+		placeholders, args = t.insertPlaceholders(record, args)
+		values = append(values, placeholders)
 	}
 
 	insertRecordsSQL += strings.Join(values, ", ")
@@ -398,24 +491,11 @@ func (t *TableOps) QueryRecord(ctx context.Context, transactionHash string) (*Co
 	// Try to use index hint if ImmutableDB supports it
 	// Note: ImmutableDB may not support index hints, but worth trying
 	queryRecordSQL := fmt.Sprintf(
-		"SELECT transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s WHERE transactionHash = ?",
-		Config.ImmuDBTable,
+		"SELECT %s FROM %s WHERE transactionHash = ?",
+		transferColumns, t.from(),
 	)
 
-	var record Config.Transfer
-	var ts time.Time
-	err := t.DB.QueryRowContext(ctx, queryRecordSQL, transactionHash).Scan(
-		&record.TransactionHash,
-		&record.From,
-		&record.To,
-		&record.BlockNumber,
-		&record.BlockHash,
-		&record.TxBlockIndex,
-		&ts,
-	)
-	if err == nil {
-		record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-	}
+	record, err := scanTransfer(t.DB.QueryRowContext(ctx, queryRecordSQL, transactionHash))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Record not found
@@ -423,135 +503,135 @@ func (t *TableOps) QueryRecord(ctx context.Context, transactionHash string) (*Co
 		return nil, fmt.Errorf("failed to query record: %w", err)
 	}
 
-	return &record, nil
+	return record, nil
 }
 
-// QueryRecordsByFrom retrieves all records by From address using ImmutableDB SQL
-// Returns a slice of Transfer records as there can be multiple transactions from the same address
-func (t *TableOps) QueryRecordsByFrom(ctx context.Context, fromAddress string) ([]*Config.Transfer, error) {
+// RecordsByFrom streams all records by From address, without loading them into memory
+// Break out of the range loop to stop early; the query is closed either way
+func (t *TableOps) RecordsByFrom(ctx context.Context, fromAddress string) iter.Seq2[*Config.Transfer, error] {
 	queryRecordsByFromSQL := fmt.Sprintf(
-		"SELECT transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s WHERE fromAddr = ?",
-		Config.ImmuDBTable,
+		"SELECT %s FROM %s WHERE fromAddr = ?",
+		transferColumns, t.from(),
 	)
+	return t.streamTransfers(ctx, queryRecordsByFromSQL, fromAddress)
+}
 
-	rows, err := t.DB.QueryContext(ctx, queryRecordsByFromSQL, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []*Config.Transfer
-	for rows.Next() {
-		var record Config.Transfer
-		var ts time.Time
-		err := rows.Scan(
-			&record.TransactionHash,
-			&record.From,
-			&record.To,
-			&record.BlockNumber,
-			&record.BlockHash,
-			&record.TxBlockIndex,
-			&ts,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan record: %w", err)
-		}
-		record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-		records = append(records, &record)
-	}
+// QueryRecordsByFrom retrieves all records by From address using ImmutableDB SQL
+// Returns a slice of Transfer records as there can be multiple transactions from the same address
+// For large histories prefer RecordsByFrom, ForEachByFrom or QueryRecordsByFromPage
+func (t *TableOps) QueryRecordsByFrom(ctx context.Context, fromAddress string) ([]*Config.Transfer, error) {
+	return collectTransfers(t.RecordsByFrom(ctx, fromAddress))
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
+// ForEachByFrom calls fn for every record by From address, stopping at the first error from the query or fn
+func (t *TableOps) ForEachByFrom(ctx context.Context, fromAddress string, fn func(*Config.Transfer) error) error {
+	return ForEach(t.RecordsByFrom(ctx, fromAddress), fn)
+}
 
-	return records, nil
+// RecordsByTo streams all records by To address, without loading them into memory
+func (t *TableOps) RecordsByTo(ctx context.Context, toAddress string) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsByToSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE toAddr = ?",
+		transferColumns, t.from(),
+	)
+	return t.streamTransfers(ctx, queryRecordsByToSQL, toAddress)
 }
 
 // QueryRecordsByTo retrieves all records by To address using ImmutableDB SQL
 // Returns a slice of Transfer records as there can be multiple transactions to the same address
+// For large histories prefer RecordsByTo, ForEachByTo or QueryRecordsByToPage
 func (t *TableOps) QueryRecordsByTo(ctx context.Context, toAddress string) ([]*Config.Transfer, error) {
-	queryRecordsByToSQL := fmt.Sprintf(
-		"SELECT transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s WHERE toAddr = ?",
-		Config.ImmuDBTable,
-	)
-	rows, err := t.DB.QueryContext(ctx, queryRecordsByToSQL, toAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []*Config.Transfer
-	for rows.Next() {
-		var record Config.Transfer
-		var ts time.Time
-		err := rows.Scan(
-			&record.TransactionHash,
-			&record.From,
-			&record.To,
-			&record.BlockNumber,
-			&record.BlockHash,
-			&record.TxBlockIndex,
-			&ts,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan record: %w", err)
-		}
-		record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-		records = append(records, &record)
-	}
+	return collectTransfers(t.RecordsByTo(ctx, toAddress))
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
+// ForEachByTo calls fn for every record by To address, stopping at the first error from the query or fn
+func (t *TableOps) ForEachByTo(ctx context.Context, toAddress string, fn func(*Config.Transfer) error) error {
+	return ForEach(t.RecordsByTo(ctx, toAddress), fn)
+}
 
-	return records, nil
+// RecordsByBlockNumber streams all records by Block Number
+func (t *TableOps) RecordsByBlockNumber(ctx context.Context, blockNumber int) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsByBlockNumberSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE blockNumber = ?",
+		transferColumns, t.from(),
+	)
+	return t.streamTransfers(ctx, queryRecordsByBlockNumberSQL, blockNumber)
 }
 
 // QueryRecordsByBlockNumber retrieves all records by Block Number using ImmutableDB SQL
 // Returns a slice of Transfer records as there can be multiple transactions at the same block number
 func (t *TableOps) QueryRecordsByBlockNumber(ctx context.Context, blockNumber int) ([]*Config.Transfer, error) {
-	queryRecordsByBlockNumberSQL := fmt.Sprintf(
-		"SELECT transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s WHERE blockNumber = ?",
-		Config.ImmuDBTable,
+	return collectTransfers(t.RecordsByBlockNumber(ctx, blockNumber))
+}
+
+// transferOrder is the ORDER BY clause used by the range queries: chain order, with id as tie-breaker
+const transferOrder = "ORDER BY blockNumber, txBlockIndex, id"
+
+// RecordsByBlockRange streams all records with fromBlock <= blockNumber <= toBlock, in chain order
+func (t *TableOps) RecordsByBlockRange(ctx context.Context, fromBlock, toBlock int) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsByBlockRangeSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE blockNumber >= ? AND blockNumber <= ? %s",
+		transferColumns, t.from(), transferOrder,
 	)
-	rows, err := t.DB.QueryContext(ctx, queryRecordsByBlockNumberSQL, blockNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []*Config.Transfer
-	for rows.Next() {
-		var record Config.Transfer
-		var ts time.Time
-		err := rows.Scan(
-			&record.TransactionHash,
-			&record.From,
-			&record.To,
-			&record.BlockNumber,
-			&record.BlockHash,
-			&record.TxBlockIndex,
-			&ts,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan record: %w", err)
-		}
-		record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-		records = append(records, &record)
-	}
+	return t.streamTransfers(ctx, queryRecordsByBlockRangeSQL, fromBlock, toBlock)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
+// QueryRecordsByBlockRange retrieves all records with fromBlock <= blockNumber <= toBlock using ImmutableDB SQL
+// Results are ordered by blockNumber, then txBlockIndex; the range is served by the index on blockNumber
+func (t *TableOps) QueryRecordsByBlockRange(ctx context.Context, fromBlock, toBlock int) ([]*Config.Transfer, error) {
+	return collectTransfers(t.RecordsByBlockRange(ctx, fromBlock, toBlock))
+}
 
-	return records, nil
+// RecordsByTimeRange streams all records with start <= ts <= end, in chain order
+func (t *TableOps) RecordsByTimeRange(ctx context.Context, start, end time.Time) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsByTimeRangeSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE ts >= ? AND ts <= ? %s",
+		transferColumns, t.from(), transferOrder,
+	)
+	return t.streamTransfers(ctx, queryRecordsByTimeRangeSQL, start.UTC(), end.UTC())
+}
+
+// QueryRecordsByTimeRange retrieves all records with start <= ts <= end using ImmutableDB SQL
+// Results are ordered by blockNumber, then txBlockIndex. ts is not indexed, so this scans the table
+func (t *TableOps) QueryRecordsByTimeRange(ctx context.Context, start, end time.Time) ([]*Config.Transfer, error) {
+	return collectTransfers(t.RecordsByTimeRange(ctx, start, end))
+}
+
+// RecordsByFromInBlockRange streams the records sent by fromAddress with fromBlock <= blockNumber <= toBlock, in chain order
+func (t *TableOps) RecordsByFromInBlockRange(ctx context.Context, fromAddress string, fromBlock, toBlock int) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE fromAddr = ? AND blockNumber >= ? AND blockNumber <= ? %s",
+		transferColumns, t.from(), transferOrder,
+	)
+	return t.streamTransfers(ctx, queryRecordsSQL, fromAddress, fromBlock, toBlock)
+}
+
+// QueryRecordsByFromInBlockRange retrieves the records sent by fromAddress with fromBlock <= blockNumber <= toBlock
+// Results are ordered by blockNumber, then txBlockIndex
+func (t *TableOps) QueryRecordsByFromInBlockRange(ctx context.Context, fromAddress string, fromBlock, toBlock int) ([]*Config.Transfer, error) {
+	return collectTransfers(t.RecordsByFromInBlockRange(ctx, fromAddress, fromBlock, toBlock))
+}
+
+// RecordsByToInBlockRange streams the records received by toAddress with fromBlock <= blockNumber <= toBlock, in chain order
+func (t *TableOps) RecordsByToInBlockRange(ctx context.Context, toAddress string, fromBlock, toBlock int) iter.Seq2[*Config.Transfer, error] {
+	queryRecordsSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE toAddr = ? AND blockNumber >= ? AND blockNumber <= ? %s",
+		transferColumns, t.from(), transferOrder,
+	)
+	return t.streamTransfers(ctx, queryRecordsSQL, toAddress, fromBlock, toBlock)
+}
+
+// QueryRecordsByToInBlockRange retrieves the records received by toAddress with fromBlock <= blockNumber <= toBlock
+// Results are ordered by blockNumber, then txBlockIndex
+func (t *TableOps) QueryRecordsByToInBlockRange(ctx context.Context, toAddress string, fromBlock, toBlock int) ([]*Config.Transfer, error) {
+	return collectTransfers(t.RecordsByToInBlockRange(ctx, toAddress, fromBlock, toBlock))
 }
 
 // CountRecords counts the number of records for a given from address using ImmutableDB SQL
 func (t *TableOps) CountRecords(ctx context.Context, fromAddress string) (int, error) {
 	countRecordsSQL := fmt.Sprintf(
 		"SELECT COUNT(*) FROM %s WHERE fromAddr = ?",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	var count int
 	err := t.DB.QueryRowContext(ctx, countRecordsSQL, fromAddress).Scan(&count)
@@ -565,7 +645,7 @@ func (t *TableOps) CountRecords(ctx context.Context, fromAddress string) (int, e
 func (t *TableOps) CountRecordsTo(ctx context.Context, toAddress string) (int, error) {
 	countRecordsSQL := fmt.Sprintf(
 		"SELECT COUNT(*) FROM %s WHERE toAddr = ?",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	var count int
 	err := t.DB.QueryRowContext(ctx, countRecordsSQL, toAddress).Scan(&count)
@@ -579,7 +659,7 @@ func (t *TableOps) CountRecordsTo(ctx context.Context, toAddress string) (int, e
 func (t *TableOps) CountAllRecords(ctx context.Context) (int, error) {
 	countRecordsSQL := fmt.Sprintf(
 		"SELECT COUNT(*) FROM %s",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	var count int
 	err := t.DB.QueryRowContext(ctx, countRecordsSQL).Scan(&count)
@@ -593,23 +673,12 @@ func (t *TableOps) CountAllRecords(ctx context.Context) (int, error) {
 // Since ID is AUTO_INCREMENT, the tail record has the maximum ID
 func (t *TableOps) GetTailRecord(ctx context.Context) (*Config.Transfer, int64, error) {
 	getTailSQL := fmt.Sprintf(
-		"SELECT id, transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s ORDER BY id DESC LIMIT 1",
-		Config.ImmuDBTable,
+		"SELECT id, %s FROM %s ORDER BY id DESC LIMIT 1",
+		transferColumns, t.from(),
 	)
 
-	var record Config.Transfer
 	var id int64
-	var ts time.Time
-	err := t.DB.QueryRowContext(ctx, getTailSQL).Scan(
-		&id,
-		&record.TransactionHash,
-		&record.From,
-		&record.To,
-		&record.BlockNumber,
-		&record.BlockHash,
-		&record.TxBlockIndex,
-		&ts,
-	)
+	record, err := scanTransfer(t.DB.QueryRowContext(ctx, getTailSQL), &id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, 0, nil // No records found
@@ -617,30 +686,18 @@ func (t *TableOps) GetTailRecord(ctx context.Context) (*Config.Transfer, int64,
 		return nil, 0, fmt.Errorf("failed to get tail record: %w", err)
 	}
 
-	record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-	return &record, id, nil
+	return record, id, nil
 }
 
 // GetHeadRecord retrieves the first inserted record (lowest ID) for O(1) lookup
 func (t *TableOps) GetHeadRecord(ctx context.Context) (*Config.Transfer, int64, error) {
 	getHeadSQL := fmt.Sprintf(
-		"SELECT id, transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s ORDER BY id ASC LIMIT 1",
-		Config.ImmuDBTable,
+		"SELECT id, %s FROM %s ORDER BY id ASC LIMIT 1",
+		transferColumns, t.from(),
 	)
 
-	var record Config.Transfer
 	var id int64
-	var ts time.Time
-	err := t.DB.QueryRowContext(ctx, getHeadSQL).Scan(
-		&id,
-		&record.TransactionHash,
-		&record.From,
-		&record.To,
-		&record.BlockNumber,
-		&record.BlockHash,
-		&record.TxBlockIndex,
-		&ts,
-	)
+	record, err := scanTransfer(t.DB.QueryRowContext(ctx, getHeadSQL), &id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, 0, nil // No records found
@@ -648,47 +705,43 @@ func (t *TableOps) GetHeadRecord(ctx context.Context) (*Config.Transfer, int64,
 		return nil, 0, fmt.Errorf("failed to get head record: %w", err)
 	}
 
-	record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-	return &record, id, nil
+	return record, id, nil
 }
 
 // GetSampleRecords retrieves a sample of records from the table
 func (t *TableOps) GetSampleRecords(ctx context.Context, limit int) ([]*Config.Transfer, error) {
 	getSampleSQL := fmt.Sprintf(
-		"SELECT transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts FROM %s ORDER BY id ASC LIMIT ?",
-		Config.ImmuDBTable,
+		"SELECT %s FROM %s ORDER BY id ASC LIMIT ?",
+		transferColumns, t.from(),
 	)
+	return collectTransfers(t.streamTransfers(ctx, getSampleSQL, limit))
+}
 
-	rows, err := t.DB.QueryContext(ctx, getSampleSQL, limit)
+// GetRandomRecords retrieves n records at random positions of the table, drawn with rng (with repeats)
+// Each draw picks an id between the head's and the tail's and reads the first record from there on,
+// so a deleted id falls through to the next record; one primary key lookup per draw
+func (t *TableOps) GetRandomRecords(ctx context.Context, n int, rng *rand.Rand) ([]*Config.Transfer, error) {
+	head, headID, err := t.GetHeadRecord(ctx)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	_, tailID, err := t.GetTailRecord(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query sample records: %w", err)
-	}
-	defer rows.Close()
-
-	var records []*Config.Transfer
-	for rows.Next() {
-		var record Config.Transfer
-		var ts time.Time
-		err := rows.Scan(
-			&record.TransactionHash,
-			&record.From,
-			&record.To,
-			&record.BlockNumber,
-			&record.BlockHash,
-			&record.TxBlockIndex,
-			&ts,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan record: %w", err)
-		}
-		record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
-		records = append(records, &record)
+		return nil, err
 	}
+	getAtSQL := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE id >= ? ORDER BY id ASC LIMIT 1",
+		transferColumns, t.from(),
+	)
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	records := make([]*Config.Transfer, 0, n)
+	for range n {
+		record, err := scanTransfer(t.DB.QueryRowContext(ctx, getAtSQL, headID+rng.Int64N(tailID-headID+1)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get random record: %w", err)
+		}
+		records = append(records, record)
 	}
-
 	return records, nil
 }
 
@@ -720,7 +773,7 @@ func (t *TableOps) GetTableStatistics(ctx context.Context) (*TableStatistics, er
 	// Get min/max block number
 	minMaxBlockSQL := fmt.Sprintf(
 		"SELECT MIN(blockNumber), MAX(blockNumber) FROM %s",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	err = t.DB.QueryRowContext(ctx, minMaxBlockSQL).Scan(&stats.MinBlockNumber, &stats.MaxBlockNumber)
 	if err != nil {
@@ -730,7 +783,7 @@ func (t *TableOps) GetTableStatistics(ctx context.Context) (*TableStatistics, er
 	// Get min/max timestamp
 	minMaxTimeSQL := fmt.Sprintf(
 		"SELECT MIN(ts), MAX(ts) FROM %s",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	var minTime, maxTime time.Time
 	err = t.DB.QueryRowContext(ctx, minMaxTimeSQL).Scan(&minTime, &maxTime)
@@ -744,7 +797,7 @@ func (t *TableOps) GetTableStatistics(ctx context.Context) (*TableStatistics, er
 	// ImmutableDB may not support COUNT(DISTINCT), so we'll query and count manually
 	uniqueFromSQL := fmt.Sprintf(
 		"SELECT fromAddr FROM %s GROUP BY fromAddr",
-		Config.ImmuDBTable,
+		t.from(),
 	)
 	rows, err := t.DB.QueryContext(ctx, uniqueFromSQL)
 	if err != nil {
@@ -759,10 +812,10 @@ func (t *TableOps) GetTableStatistics(ctx context.Context) (*TableStatistics, er
 		stats.UniqueFromAddrs = count
 	}
 
-	// Get unique to addresses count
+	// Get unique to addresses count, without the NULL toAddr of contract creations
 	uniqueToSQL := fmt.Sprintf(
-		"SELECT toAddr FROM %s GROUP BY toAddr",
-		Config.ImmuDBTable,
+		"SELECT toAddr FROM %s WHERE toAddr IS NOT NULL GROUP BY toAddr",
+		t.from(),
 	)
 	rows, err = t.DB.QueryContext(ctx, uniqueToSQL)
 	if err != nil {
@@ -0,0 +1,1132 @@
+package IMMUSQL
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+)
+
+/*
+- Integration tests for TableOps against a throwaway immudb
+- TestMain starts an embedded in-process immudb on a temp dir (removed by IMMUDB.CloseAll),
+  so no server has to be running
+- fixtureTable is created and seeded once with testTransfers and is only read from;
+  tests that write or need an empty table get their own with newTable
+*/
+
+const (
+	testDatabase  = "tableopstest"
+	fixtureTable  = "fixturetransfers"
+	testBlockSize = 40 // transfers per block in testTransfers
+	testBaseTime  = int64(1700000000)
+)
+
+// testAddressSet are the addresses used by testTransfers; the last one only ever receives
+var testAddressSet = []string{
+	"0x1111111111111111111111111111111111111111",
+	"0x2222222222222222222222222222222222222222",
+	"0x3333333333333333333333333333333333333333",
+	"0x4444444444444444444444444444444444444444",
+}
+
+// testOps is bound to fixtureTable, fixture holds the records inserted into it
+var (
+	testOps *TableOps
+	fixture []Config.Transfer
+)
+
+func TestMain(m *testing.M) {
+	code, err := runTests(m)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+// runTests connects to an embedded immudb, seeds fixtureTable and runs the tests
+func runTests(m *testing.M) (int, error) {
+	settings := Config.Default()
+	settings.Backend = Config.BackendEmbedded
+	settings.Database = testDatabase
+	settings.Table = fixtureTable
+	defer IMMUDB.CloseAll()
+
+	testOps = GetTableOps(settings)
+	ctx := context.Background()
+	if err := testOps.CreateTable(ctx); err != nil {
+		return 0, err
+	}
+	// More than one InsertRecords batch, with a partial last batch
+	fixture = testTransfers(450)
+	if err := testOps.InsertRecords(ctx, fixture); err != nil {
+		return 0, fmt.Errorf("failed to seed %s: %w", fixtureTable, err)
+	}
+	return m.Run(), nil
+}
+
+// testTransfers returns count deterministic transfers in chain order, testBlockSize per block
+// Every 7th transfer is a self-transfer
+func testTransfers(count int) []Config.Transfer {
+	transfers := make([]Config.Transfer, 0, count)
+	senders := len(testAddressSet) - 1
+	for i := 0; i < count; i++ {
+		block := 100 + i/testBlockSize
+		from := testAddressSet[i%senders]
+		to := testAddressSet[(i+1)%len(testAddressSet)]
+		if i%7 == 0 {
+			to = from
+		}
+		transfers = append(transfers, Config.Transfer{
+			From:            from,
+			To:              to,
+			BlockNumber:     block,
+			TransactionHash: fmt.Sprintf("0x%064x", i+1),
+			BlockHash:       fmt.Sprintf("0x%064x", 1<<32+block),
+			TxBlockIndex:    i % testBlockSize,
+			Timestamp:       testBaseTime + int64(block)*12,
+		})
+	}
+	return transfers
+}
+
+// nonIdentifier matches the characters of a test name that can't be used in a table name
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// newTable returns testOps bound to a fresh, empty table (with indexes) that is dropped after the test
+func newTable(t *testing.T) *TableOps {
+	t.Helper()
+	ctx := context.Background()
+	name := "t_" + nonIdentifier.ReplaceAllString(strings.ToLower(t.Name()), "_")
+	ops := testOps.Table(name)
+	if err := ops.DropTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ops.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := ops.DropTable(context.Background()); err != nil {
+			t.Errorf("failed to drop %s: %v", name, err)
+		}
+	})
+	return ops
+}
+
+// matching returns the fixture records for which keep is true, in chain order
+func matching(keep func(Config.Transfer) bool) []Config.Transfer {
+	var want []Config.Transfer
+	for _, record := range fixture {
+		if keep(record) {
+			want = append(want, record)
+		}
+	}
+	return want
+}
+
+// deref copies records into values so they compare with ==
+func deref(records []*Config.Transfer) []Config.Transfer {
+	values := make([]Config.Transfer, 0, len(records))
+	for _, record := range records {
+		values = append(values, *record)
+	}
+	return values
+}
+
+// chainOrder sorts transfers by blockNumber, then txBlockIndex
+func chainOrder(records []Config.Transfer) {
+	slices.SortFunc(records, func(a, b Config.Transfer) int {
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber - b.BlockNumber
+		}
+		return a.TxBlockIndex - b.TxBlockIndex
+	})
+}
+
+// diffTransfers reports the first difference between got and want
+func diffTransfers(t *testing.T, got, want []Config.Transfer) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("record %d:\n got  %+v\n want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestInsertRecordsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, want := range fixture {
+		got, err := testOps.QueryRecord(ctx, want.TransactionHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			t.Fatalf("record %s not found", want.TransactionHash)
+		}
+		if *got != want {
+			t.Fatalf("round trip mismatch:\n got  %+v\n want %+v", *got, want)
+		}
+	}
+}
+
+func TestInsertRecord(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	want := testTransfers(1)[0]
+	if err := ops.InsertRecord(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ops.QueryRecord(ctx, want.TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestContractCreation(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	records := testTransfers(3)
+	records[1].To = "" // contract creation: no recipient
+	if err := ops.InsertRecords(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ops.QueryRecord(ctx, records[1].TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != records[1] {
+		t.Fatalf("got %+v, want %+v", got, records[1])
+	}
+
+	var nulls int
+	if err := ops.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+ops.TableName()+" WHERE toAddr IS NULL").Scan(&nulls); err != nil {
+		t.Fatal(err)
+	}
+	if nulls != 1 {
+		t.Fatalf("got %d NULL toAddr rows, want 1", nulls)
+	}
+}
+
+func TestInsertRecordsEmpty(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	if err := ops.InsertRecords(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	count, err := ops.CountAllRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("got %d records, want 0", count)
+	}
+}
+
+func TestInsertRecordsBatchSize(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t).WithBatchSize(7)
+
+	records := testTransfers(30)
+	if err := ops.InsertRecords(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ops.GetSampleRecords(ctx, len(records)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffTransfers(t, deref(got), records)
+}
+
+func TestGetRandomRecords(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	records := testTransfers(40)
+	if err := ops.InsertRecords(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ops.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id <= 10", ops.TableName())); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ops.GetRandomRecords(ctx, 200, mathrand.New(mathrand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 200 {
+		t.Fatalf("got %d records, want 200", len(got))
+	}
+	seen := make(map[string]bool)
+	for _, record := range got {
+		seen[record.TransactionHash] = true
+	}
+	for _, record := range records[:10] {
+		if seen[record.TransactionHash] {
+			t.Errorf("deleted record %s was sampled", record.TransactionHash)
+		}
+	}
+	if len(seen) < 25 {
+		t.Errorf("sampled %d distinct records of the 30 left, want most of them", len(seen))
+	}
+	if !seen[records[len(records)-1].TransactionHash] {
+		t.Error("the tail record was never sampled")
+	}
+}
+
+func TestServerTimestamps(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t).WithServerTimestamps(true)
+
+	record := testTransfers(1)[0]
+	before := time.Now().Add(-time.Minute).Unix()
+	if err := ops.InsertRecord(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ops.QueryRecord(ctx, record.TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("record not found")
+	}
+	if got.Timestamp < before {
+		t.Fatalf("got ts %d, want the server time (>= %d)", got.Timestamp, before)
+	}
+}
+
+func TestQueryRecordNotFound(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		ops  func(t *testing.T) *TableOps
+	}{
+		{"populated", func(*testing.T) *TableOps { return testOps }},
+		{"empty", newTable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := tt.ops(t).QueryRecord(ctx, fmt.Sprintf("0x%064x", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record != nil {
+				t.Fatalf("got %+v, want nil", record)
+			}
+		})
+	}
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	sender := testAddressSet[0]
+	receiver := testAddressSet[len(testAddressSet)-1]
+	start := time.Unix(testBaseTime+102*12, 0)
+	end := time.Unix(testBaseTime+104*12, 0)
+
+	tests := []struct {
+		name    string
+		query   func() ([]*Config.Transfer, error)
+		want    func(Config.Transfer) bool
+		ordered bool // results must come back in chain order
+	}{
+		{
+			name:  "from",
+			query: func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByFrom(ctx, sender) },
+			want:  func(r Config.Transfer) bool { return r.From == sender },
+		},
+		{
+			name:  "to",
+			query: func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByTo(ctx, receiver) },
+			want:  func(r Config.Transfer) bool { return r.To == receiver },
+		},
+		{
+			name:  "from unknown address",
+			query: func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByFrom(ctx, "0x0") },
+			want:  func(Config.Transfer) bool { return false },
+		},
+		{
+			name:  "block number",
+			query: func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByBlockNumber(ctx, 103) },
+			want:  func(r Config.Transfer) bool { return r.BlockNumber == 103 },
+		},
+		{
+			name:    "block range",
+			query:   func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByBlockRange(ctx, 102, 105) },
+			want:    func(r Config.Transfer) bool { return r.BlockNumber >= 102 && r.BlockNumber <= 105 },
+			ordered: true,
+		},
+		{
+			name:    "empty block range",
+			query:   func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByBlockRange(ctx, 105, 102) },
+			want:    func(Config.Transfer) bool { return false },
+			ordered: true,
+		},
+		{
+			name:  "time range",
+			query: func() ([]*Config.Transfer, error) { return testOps.QueryRecordsByTimeRange(ctx, start, end) },
+			want: func(r Config.Transfer) bool {
+				return r.Timestamp >= start.Unix() && r.Timestamp <= end.Unix()
+			},
+			ordered: true,
+		},
+		{
+			name: "from in block range",
+			query: func() ([]*Config.Transfer, error) {
+				return testOps.QueryRecordsByFromInBlockRange(ctx, sender, 101, 103)
+			},
+			want: func(r Config.Transfer) bool {
+				return r.From == sender && r.BlockNumber >= 101 && r.BlockNumber <= 103
+			},
+			ordered: true,
+		},
+		{
+			name: "to in block range",
+			query: func() ([]*Config.Transfer, error) {
+				return testOps.QueryRecordsByToInBlockRange(ctx, receiver, 101, 103)
+			},
+			want: func(r Config.Transfer) bool {
+				return r.To == receiver && r.BlockNumber >= 101 && r.BlockNumber <= 103
+			},
+			ordered: true,
+		},
+		{
+			name:    "sample",
+			query:   func() ([]*Config.Transfer, error) { return testOps.GetSampleRecords(ctx, 25) },
+			want:    func(r Config.Transfer) bool { return r.BlockNumber == 100 && r.TxBlockIndex < 25 },
+			ordered: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := tt.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := deref(records)
+			if !tt.ordered {
+				chainOrder(got)
+			}
+			diffTransfers(t, got, matching(tt.want))
+		})
+	}
+}
+
+func TestAddressPages(t *testing.T) {
+	ctx := context.Background()
+	address := testAddressSet[1]
+
+	tests := []struct {
+		name  string
+		query func(cursor Cursor) (*Page, error)
+		want  func(Config.Transfer) bool
+	}{
+		{
+			name:  "from",
+			query: func(c Cursor) (*Page, error) { return testOps.QueryRecordsByFromPage(ctx, address, 17, c) },
+			want:  func(r Config.Transfer) bool { return r.From == address },
+		},
+		{
+			name:  "to",
+			query: func(c Cursor) (*Page, error) { return testOps.QueryRecordsByToPage(ctx, address, 17, c) },
+			want:  func(r Config.Transfer) bool { return r.To == address },
+		},
+		{
+			name: "both",
+			query: func(c Cursor) (*Page, error) {
+				return testOps.QueryRecordsByAddress(ctx, address, DirectionBoth, 17, c)
+			},
+			want: func(r Config.Transfer) bool { return r.From == address || r.To == address },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Config.Transfer
+			var cursor Cursor
+			for {
+				page, err := tt.query(cursor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page.Records) > 17 {
+					t.Fatalf("page has %d records, want at most 17", len(page.Records))
+				}
+				got = append(got, deref(page.Records)...)
+				if page.Next == "" {
+					break
+				}
+				cursor = page.Next
+			}
+			diffTransfers(t, got, matching(tt.want))
+		})
+	}
+}
+
+func TestCounts(t *testing.T) {
+	ctx := context.Background()
+
+	type countTest struct {
+		name  string
+		count func() (int, error)
+		want  func(Config.Transfer) bool
+	}
+	tests := []countTest{
+		{
+			name:  "all",
+			count: func() (int, error) { return testOps.CountAllRecords(ctx) },
+			want:  func(Config.Transfer) bool { return true },
+		},
+		{
+			name:  "unknown address",
+			count: func() (int, error) { return testOps.CountRecords(ctx, "0x0") },
+			want:  func(Config.Transfer) bool { return false },
+		},
+	}
+	for _, address := range testAddressSet {
+		tests = append(tests,
+			countTest{
+				name:  "from " + address,
+				count: func() (int, error) { return testOps.CountRecords(ctx, address) },
+				want:  func(r Config.Transfer) bool { return r.From == address },
+			},
+			countTest{
+				name:  "to " + address,
+				count: func() (int, error) { return testOps.CountRecordsTo(ctx, address) },
+				want:  func(r Config.Transfer) bool { return r.To == address },
+			},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.count()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := len(matching(tt.want)); got != want {
+				t.Fatalf("got %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestHeadTailRecord(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	// Empty table: nil, 0, nil
+	for name, get := range map[string]func(context.Context) (*Config.Transfer, int64, error){
+		"head": ops.GetHeadRecord,
+		"tail": ops.GetTailRecord,
+	} {
+		record, id, err := get(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if record != nil || id != 0 {
+			t.Fatalf("%s of empty table: got %+v (id %d), want nil", name, record, id)
+		}
+	}
+
+	records := testTransfers(5)
+	if err := ops.InsertRecords(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+
+	head, headID, err := ops.GetHeadRecord(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail, tailID, err := ops.GetTailRecord(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head == nil || *head != records[0] {
+		t.Fatalf("head: got %+v, want %+v", head, records[0])
+	}
+	if tail == nil || *tail != records[len(records)-1] {
+		t.Fatalf("tail: got %+v, want %+v", tail, records[len(records)-1])
+	}
+	if tailID-headID != int64(len(records)-1) {
+		t.Fatalf("got head id %d and tail id %d, want %d apart", headID, tailID, len(records)-1)
+	}
+}
+
+func TestGetTableStatistics(t *testing.T) {
+	ctx := context.Background()
+
+	unique := func(address func(Config.Transfer) string) int {
+		seen := map[string]bool{}
+		for _, record := range fixture {
+			if address(record) != "" {
+				seen[address(record)] = true
+			}
+		}
+		return len(seen)
+	}
+	last := fixture[len(fixture)-1]
+
+	tests := []struct {
+		name string
+		ops  func(t *testing.T) *TableOps
+		want TableStatistics
+	}{
+		{
+			name: "empty",
+			ops:  newTable,
+			want: TableStatistics{},
+		},
+		{
+			name: "populated",
+			ops:  func(*testing.T) *TableOps { return testOps },
+			want: TableStatistics{
+				TotalRecords:    len(fixture),
+				MinBlockNumber:  fixture[0].BlockNumber,
+				MaxBlockNumber:  last.BlockNumber,
+				MinTimestamp:    fixture[0].Timestamp,
+				MaxTimestamp:    last.Timestamp,
+				UniqueFromAddrs: unique(func(r Config.Transfer) string { return r.From }),
+				UniqueToAddrs:   unique(func(r Config.Transfer) string { return r.To }),
+			},
+		},
+		{
+			// The NULL toAddr of a contract creation is not an address
+			name: "contract creation",
+			ops: func(t *testing.T) *TableOps {
+				ops := newTable(t)
+				creation := fixture[1]
+				creation.To = ""
+				if err := ops.InsertRecords(ctx, []Config.Transfer{fixture[0], creation}); err != nil {
+					t.Fatal(err)
+				}
+				return ops
+			},
+			want: TableStatistics{
+				TotalRecords:    2,
+				MinBlockNumber:  fixture[0].BlockNumber,
+				MaxBlockNumber:  fixture[1].BlockNumber,
+				MinTimestamp:    fixture[0].Timestamp,
+				MaxTimestamp:    fixture[1].Timestamp,
+				UniqueFromAddrs: 2,
+				UniqueToAddrs:   1,
+			},
+		},
+		{
+			// The address columns are missing, so GROUP BY fails and the unique counts fall back to -1
+			name: "unique count fallback",
+			ops:  newTableWithoutAddresses,
+			want: TableStatistics{
+				TotalRecords:    1,
+				MinBlockNumber:  7,
+				MaxBlockNumber:  7,
+				MinTimestamp:    testBaseTime,
+				MaxTimestamp:    testBaseTime,
+				UniqueFromAddrs: -1,
+				UniqueToAddrs:   -1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := tt.ops(t).GetTableStatistics(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *stats != tt.want {
+				t.Fatalf("got %+v, want %+v", *stats, tt.want)
+			}
+		})
+	}
+}
+
+// newTableWithoutAddresses returns a fresh table with a single row and no fromAddr/toAddr columns
+func newTableWithoutAddresses(t *testing.T) *TableOps {
+	t.Helper()
+	ctx := context.Background()
+	ops := newTable(t)
+	if err := ops.DropTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	createSQL := fmt.Sprintf(
+		"CREATE TABLE %s (id INTEGER AUTO_INCREMENT, blockNumber INTEGER NOT NULL, ts TIMESTAMP NOT NULL, PRIMARY KEY (id))",
+		ops.TableName(),
+	)
+	if _, err := ops.DB.ExecContext(ctx, createSQL); err != nil {
+		t.Fatal(err)
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO %s (blockNumber, ts) VALUES (?, ?)", ops.TableName())
+	if _, err := ops.DB.ExecContext(ctx, insertSQL, 7, time.Unix(testBaseTime, 0).UTC()); err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func TestExportRecords(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+
+	records := testTransfers(150)
+	if err := ops.InsertRecords(ctx, records[:100]); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := ops.SnapshotTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Committed after the snapshot, so left out of exports pinned to tx
+	if err := ops.InsertRecords(ctx, records[100:]); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := ops.SnapshotTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(r ExportRange, tx uint64) []Config.Transfer {
+		t.Helper()
+		var got []Config.Transfer
+		var last int64
+		for record, err := range ops.ExportRecords(ctx, r, tx, 30) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.ID <= last {
+				t.Fatalf("id %d after %d, want id order", record.ID, last)
+			}
+			last = record.ID
+			got = append(got, record.Transfer)
+		}
+		return got
+	}
+	inRange := func(keep func(Config.Transfer) bool) []Config.Transfer {
+		var want []Config.Transfer
+		for _, record := range records {
+			if keep(record) {
+				want = append(want, record)
+			}
+		}
+		return want
+	}
+
+	block := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		r    ExportRange
+		tx   uint64
+		want []Config.Transfer
+	}{
+		{"snapshot", ExportRange{}, tx, records[:100]},
+		{"latest", ExportRange{}, latest, records},
+		{"blocks", ExportRange{FromBlock: 101, ToBlock: block(102)}, latest, inRange(func(r Config.Transfer) bool {
+			return r.BlockNumber >= 101 && r.BlockNumber <= 102
+		})},
+		{"blocks in snapshot", ExportRange{FromBlock: 102}, tx, records[80:100]}, // the snapshot ends inside block 102
+		{"up to block 0", ExportRange{ToBlock: block(0)}, latest, nil},
+		{"time", ExportRange{Start: time.Unix(testBaseTime+101*12, 0), End: time.Unix(testBaseTime+101*12, 0)}, latest,
+			inRange(func(r Config.Transfer) bool { return r.BlockNumber == 101 })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := export(tt.r, tt.tx); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %d records, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestVerifiedReads(t *testing.T) {
+	ctx := context.Background()
+	sender := testAddressSet[0]
+	receiver := testAddressSet[len(testAddressSet)-1]
+
+	want := fixture[123]
+	got, err := testOps.VerifiedQueryRecord(ctx, want.TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != want {
+		t.Fatalf("VerifiedQueryRecord: got %+v, want %+v", got, want)
+	}
+	missing, err := testOps.VerifiedQueryRecord(ctx, fmt.Sprintf("0x%064x", 0))
+	if err != nil || missing != nil {
+		t.Fatalf("VerifiedQueryRecord of a missing hash: got %+v, %v, want nil, nil", missing, err)
+	}
+
+	tests := []struct {
+		name  string
+		query func() ([]*Config.Transfer, error)
+		want  func(Config.Transfer) bool
+	}{
+		{
+			name:  "from",
+			query: func() ([]*Config.Transfer, error) { return testOps.VerifiedQueryRecordsByFrom(ctx, sender) },
+			want:  func(r Config.Transfer) bool { return r.From == sender },
+		},
+		{
+			name:  "to",
+			query: func() ([]*Config.Transfer, error) { return testOps.VerifiedQueryRecordsByTo(ctx, receiver) },
+			want:  func(r Config.Transfer) bool { return r.To == receiver },
+		},
+		{
+			name:  "block number",
+			query: func() ([]*Config.Transfer, error) { return testOps.VerifiedQueryRecordsByBlockNumber(ctx, 105) },
+			want:  func(r Config.Transfer) bool { return r.BlockNumber == 105 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := tt.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := deref(records)
+			chainOrder(got)
+			diffTransfers(t, got, matching(tt.want))
+		})
+	}
+}
+
+func TestVerifiedReadDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	want := fixture[7]
+	querySQL := fmt.Sprintf("SELECT id, %s FROM %s WHERE transactionHash = @value", transferColumns, testOps.TableName())
+
+	err := IMMUDB.WithClient(ctx, testOps.DB, func(c client.ImmuClient) error {
+		result, err := c.SQLQuery(ctx, querySQL, map[string]interface{}{"value": want.TransactionHash}, false)
+		if err != nil || len(result.Rows) != 1 {
+			t.Fatalf("got %v rows, %v; want 1 row", len(result.GetRows()), err)
+		}
+		row := result.Rows[0]
+		id := row.Values[0].GetN()
+		if err := testOps.verifyRow(ctx, c, row, id); err != nil {
+			t.Fatalf("untouched row: %v", err)
+		}
+		// What a server rewriting the row in flight would send
+		row.Values[2] = &schema.SQLValue{Value: &schema.SQLValue_S{S: testAddressSet[3]}}
+		return testOps.verifyRow(ctx, c, row, id)
+	})
+	if !errors.Is(err, ErrVerification) {
+		t.Fatalf("altered row: got %v, want ErrVerification", err)
+	}
+}
+
+func TestVerifiedReadDetectsWrongRow(t *testing.T) {
+	ctx := context.Background()
+	want := fixture[7]
+	// What a server answering with a real row for another hash would send
+	querySQL := fmt.Sprintf("SELECT id, %s FROM %s WHERE transactionHash <> @value LIMIT 1", transferColumns, testOps.TableName())
+	_, err := testOps.verifiedQuery(ctx, querySQL, want.TransactionHash, func(r *Config.Transfer) bool {
+		return r.TransactionHash == want.TransactionHash
+	})
+	if !errors.Is(err, ErrVerification) {
+		t.Fatalf("row of another hash: got %v, want ErrVerification", err)
+	}
+}
+
+func TestProofBundle(t *testing.T) {
+	ctx := context.Background()
+	want := fixture[11]
+	bundle, err := testOps.ExportProof(ctx, want.TransactionHash)
+	if err != nil || bundle == nil {
+		t.Fatalf("got %v, %v; want a bundle", bundle, err)
+	}
+	if bundle.Record.Transfer != want || bundle.State.TxID < bundle.Tx.ID {
+		t.Fatalf("got record %+v at tx %d, state tx %d; want %+v", bundle.Record, bundle.Tx.ID, bundle.State.TxID, want)
+	}
+	if missing, err := testOps.ExportProof(ctx, "0x"+strings.Repeat("0", 64)); missing != nil || err != nil {
+		t.Fatalf("unknown hash: got %v, %v; want nil, nil", missing, err)
+	}
+
+	// Offline, from its JSON
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := func() *ProofBundle {
+		var b ProofBundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			t.Fatal(err)
+		}
+		return &b
+	}
+	if _, err := VerifyProofBundle(load(), nil); err != nil {
+		t.Fatalf("untouched bundle: %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProofBundle(load(), &key.PublicKey); !errors.Is(err, ErrVerification) {
+		t.Fatalf("unsigned state with a public key: got %v, want ErrVerification", err)
+	}
+
+	replace := func(raw json.RawMessage, old, new string) json.RawMessage {
+		if !strings.Contains(string(raw), old) {
+			t.Fatalf("%s not in %s", old, raw)
+		}
+		return json.RawMessage(strings.Replace(string(raw), old, new, 1))
+	}
+	for _, tc := range []struct {
+		name   string
+		tamper func(*ProofBundle)
+	}{
+		{"record", func(b *ProofBundle) { b.Record.From = testAddressSet[3] }},
+		{"tx time", func(b *ProofBundle) { b.Tx.Timestamp = b.Tx.Timestamp.Add(time.Hour) }},
+		{"state", func(b *ProofBundle) { b.State.TxID++ }},
+		{"row", func(b *ProofBundle) { b.Row = replace(b.Row, want.From, testAddressSet[3]) }},
+		{"stored row", func(b *ProofBundle) {
+			var entry schema.VerifiableSQLEntry
+			if err := protojson.Unmarshal(b.Entry, &entry); err != nil {
+				t.Fatal(err)
+			}
+			entry.SqlEntry.Value[len(entry.SqlEntry.Value)-1] ^= 1
+			b.Entry, _ = protojson.Marshal(&entry)
+		}},
+		{"dual proof", func(b *ProofBundle) {
+			var entry schema.VerifiableSQLEntry
+			if err := protojson.Unmarshal(b.Entry, &entry); err != nil {
+				t.Fatal(err)
+			}
+			entry.VerifiableTx.DualProof.TargetTxHeader.Ts++
+			b.Entry, _ = protojson.Marshal(&entry)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := load()
+			tc.tamper(b)
+			if _, err := VerifyProofBundle(b, nil); !errors.Is(err, ErrVerification) {
+				t.Fatalf("got %v, want ErrVerification", err)
+			}
+		})
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+	before, after := fixture[:200], fixture[200:300]
+	if err := ops.InsertRecords(ctx, before); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := ops.SnapshotTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Commit times have a resolution of one second
+	time.Sleep(1100 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+	if err := ops.InsertRecords(ctx, after); err != nil {
+		t.Fatal(err)
+	}
+
+	address := testAddressSet[0]
+	fromAddress := func(records []Config.Transfer) []Config.Transfer {
+		var want []Config.Transfer
+		for _, r := range records {
+			if r.From == address {
+				want = append(want, r)
+			}
+		}
+		return want
+	}
+	for _, tc := range []struct {
+		name string
+		ops  *TableOps
+		want []Config.Transfer
+	}{
+		{"now", ops.AsOf(AsOf{}), fixture[:300]},
+		{"tx", ops.AsOf(AsOfTx(tx)), before},
+		{"time", ops.AsOf(AsOfTime(at)), before},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			last := tc.want[len(tc.want)-1]
+			if record, err := tc.ops.QueryRecord(ctx, last.TransactionHash); err != nil || record == nil || *record != last {
+				t.Fatalf("last record: got %v, %v; want %+v", record, err, last)
+			}
+			if tc.name != "now" {
+				if record, err := tc.ops.QueryRecord(ctx, after[0].TransactionHash); record != nil || err != nil {
+					t.Fatalf("later record: got %v, %v; want nil, nil", record, err)
+				}
+			}
+			got, err := tc.ops.QueryRecordsByFrom(ctx, address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diffTransfers(t, deref(got), fromAddress(tc.want))
+
+			page, err := tc.ops.QueryRecordsByAddress(ctx, address, DirectionOut, 1000, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Records) != len(fromAddress(tc.want)) {
+				t.Fatalf("address page: got %d records, want %d", len(page.Records), len(fromAddress(tc.want)))
+			}
+			if n, err := tc.ops.CountAllRecords(ctx); err != nil || n != len(tc.want) {
+				t.Fatalf("count: got %d, %v; want %d", n, err, len(tc.want))
+			}
+			stats, err := tc.ops.GetTableStatistics(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.TotalRecords != len(tc.want) || stats.MaxBlockNumber != last.BlockNumber {
+				t.Fatalf("statistics: got %d records up to block %d, want %d up to %d",
+					stats.TotalRecords, stats.MaxBlockNumber, len(tc.want), last.BlockNumber)
+			}
+		})
+	}
+}
+
+func TestHistoryOfRecord(t *testing.T) {
+	ctx := context.Background()
+	ops := newTable(t)
+	exec := func(query string, args ...any) uint64 {
+		t.Helper()
+		if query != "" {
+			if _, err := ops.DB.ExecContext(ctx, fmt.Sprintf(query, ops.TableName()), args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tx, err := ops.SnapshotTx(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	if err := ops.InsertRecords(ctx, fixture[:10]); err != nil {
+		t.Fatal(err)
+	}
+	inserted := exec("")
+	// Other writes in between, so revisions are not in consecutive txs
+	filler := func(i int) {
+		if err := ops.InsertRecords(ctx, fixture[10+i:11+i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filler(0)
+	updatedA := exec("UPDATE %s SET blockNumber = 7 WHERE transactionHash = ?", fixture[1].TransactionHash)
+	filler(1)
+	updatedB := exec("UPDATE %s SET toAddr = ? WHERE transactionHash = ?", "0x"+strings.Repeat("5", 40), fixture[5].TransactionHash)
+	filler(2)
+	filler(3)
+	updatedA2 := exec("UPDATE %s SET fromAddr = ?, txBlockIndex = 99 WHERE transactionHash = ?", testAddressSet[3], fixture[1].TransactionHash)
+	deletedA := exec("DELETE FROM %s WHERE transactionHash = ?", fixture[1].TransactionHash)
+	filler(4)
+
+	type revision struct {
+		tx      uint64
+		deleted bool
+		columns []string
+	}
+	for _, tc := range []struct {
+		name      string
+		record    Config.Transfer
+		revisions []revision
+		deleted   bool
+		altered   bool
+	}{
+		{"updated and deleted", fixture[1], []revision{
+			{inserted, false, nil},
+			{updatedA, false, []string{"blockNumber"}},
+			{updatedA2, false, []string{"fromAddr", "txBlockIndex"}},
+			{deletedA, true, nil},
+		}, true, true},
+		{"updated", fixture[5], []revision{
+			{inserted, false, nil},
+			{updatedB, false, []string{"toAddr"}},
+		}, false, true},
+		{"untouched", fixture[6], []revision{{inserted, false, nil}}, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			histories, err := ops.HistoryOfRecord(ctx, tc.record.TransactionHash, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(histories) != 1 {
+				t.Fatalf("got %d histories, want 1", len(histories))
+			}
+			h := histories[0]
+			if h.Deleted != tc.deleted || h.Altered() != tc.altered {
+				t.Fatalf("got deleted %v, altered %v; want %v, %v", h.Deleted, h.Altered(), tc.deleted, tc.altered)
+			}
+			if len(h.Revisions) != len(tc.revisions) {
+				t.Fatalf("got %d revisions, want %d: %+v", len(h.Revisions), len(tc.revisions), h.Revisions)
+			}
+			for i, want := range tc.revisions {
+				got := h.Revisions[i]
+				var columns []string
+				for _, c := range got.Changes {
+					columns = append(columns, c.Column)
+				}
+				if got.Revision != i+1 || got.TxID != want.tx || got.Deleted != want.deleted ||
+					!slices.Equal(columns, want.columns) || got.CommittedAt.IsZero() {
+					t.Errorf("revision %d: got %d at tx %d, deleted %v, changes %v; want tx %d, deleted %v, changes %v",
+						i+1, got.Revision, got.TxID, got.Deleted, columns, want.tx, want.deleted, want.columns)
+				}
+			}
+			if got := h.Revisions[0].Transfer; got != tc.record {
+				t.Errorf("insert: got %+v, want %+v", got, tc.record)
+			}
+		})
+	}
+
+	// Without removed only the current rows are found
+	if histories, err := ops.HistoryOfRecord(ctx, fixture[1].TransactionHash, false); histories != nil || err != nil {
+		t.Fatalf("deleted row without removed: got %v, %v; want nil, nil", histories, err)
+	}
+	if histories, err := ops.HistoryOfRecord(ctx, fixture[5].TransactionHash, false); err != nil || len(histories) != 1 ||
+		len(histories[0].Revisions) != 2 {
+		t.Fatalf("updated row without removed: got %v, %v; want its 2 revisions", histories, err)
+	}
+
+	// Inserted again after the delete, under a new id
+	if err := ops.InsertRecord(ctx, fixture[1]); err != nil {
+		t.Fatal(err)
+	}
+	histories, err := ops.HistoryOfRecord(ctx, fixture[1].TransactionHash, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(histories) != 2 || !histories[0].Deleted || histories[1].Altered() || histories[1].ID <= histories[0].ID {
+		t.Fatalf("got %d histories, want the deleted row and the new one", len(histories))
+	}
+	if current, err := ops.HistoryOfRecord(ctx, fixture[1].TransactionHash, false); err != nil || len(current) != 1 ||
+		current[0].ID != histories[1].ID {
+		t.Fatalf("without removed: got %v, %v; want only the new row", current, err)
+	}
+	want := FieldChange{Column: "blockNumber", Old: fmt.Sprint(fixture[1].BlockNumber), New: "7"}
+	if got := histories[0].Revisions[1].Changes; got[0] != want {
+		t.Errorf("changes: got %+v, want %+v", got, want)
+	}
+	if histories, err := ops.HistoryOfRecord(ctx, "0x"+strings.Repeat("0", 64), true); histories != nil || err != nil {
+		t.Fatalf("unknown hash: got %v, %v; want nil, nil", histories, err)
+	}
+}
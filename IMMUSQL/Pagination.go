@@ -0,0 +1,179 @@
+package IMMUSQL
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"DBTests/Config"
+)
+
+/*
+- Cursor (keyset) pagination for address history queries
+- Pages are ordered by (blockNumber, txBlockIndex, id) and each page starts strictly after the
+  last row of the previous one, so a deep page costs the same as the first one (no OFFSET)
+- The lookups are served by the composite indexes (fromAddr|toAddr, blockNumber, txBlockIndex)
+  created by CreateTable; id is the primary key and breaks ties inside an index entry
+
+Usage:
+
+	var cursor IMMUSQL.Cursor
+	for {
+		page, err := ops.QueryRecordsByFromPage(ctx, addr, 100, cursor)
+		...
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+*/
+
+// Cursor is an opaque position in an address history. The empty cursor means "from the start"
+type Cursor string
+
+// Page is one page of an address history
+// Next is the cursor for the following page, empty when this is the last page
+type Page struct {
+	Records []*Config.Transfer
+	Next    Cursor
+}
+
+// cursorKey is the decoded form of a Cursor: the sort key of the last row of a page
+type cursorKey struct {
+	BlockNumber  int
+	TxBlockIndex int
+	ID           int64
+}
+
+// NewCursor builds the cursor pointing just after the row with the given sort key
+func NewCursor(blockNumber, txBlockIndex int, id int64) Cursor {
+	raw := fmt.Sprintf("%d:%d:%d", blockNumber, txBlockIndex, id)
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+}
+
+// decode returns the sort key encoded in c
+func (c Cursor) decode() (cursorKey, error) {
+	var key cursorKey
+	raw, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return key, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if _, err := fmt.Sscanf(string(raw), "%d:%d:%d", &key.BlockNumber, &key.TxBlockIndex, &key.ID); err != nil {
+		return key, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return key, nil
+}
+
+// QueryRecordsByFromPage retrieves one page of the records sent by fromAddress, in chain order
+// Pass an empty cursor for the first page and page.Next for the following ones
+func (t *TableOps) QueryRecordsByFromPage(ctx context.Context, fromAddress string, pageSize int, cursor Cursor) (*Page, error) {
+	return t.queryAddressPage(ctx, "fromAddr", fromAddress, pageSize, cursor)
+}
+
+// QueryRecordsByToPage retrieves one page of the records received by toAddress, in chain order
+// Pass an empty cursor for the first page and page.Next for the following ones
+func (t *TableOps) QueryRecordsByToPage(ctx context.Context, toAddress string, pageSize int, cursor Cursor) (*Page, error) {
+	return t.queryAddressPage(ctx, "toAddr", toAddress, pageSize, cursor)
+}
+
+// keyedTransfer is a record together with its sort key
+type keyedTransfer struct {
+	key    cursorKey
+	record *Config.Transfer
+}
+
+// before reports whether k sorts before other in chain order
+func (k cursorKey) before(other cursorKey) bool {
+	if k.BlockNumber != other.BlockNumber {
+		return k.BlockNumber < other.BlockNumber
+	}
+	if k.TxBlockIndex != other.TxBlockIndex {
+		return k.TxBlockIndex < other.TxBlockIndex
+	}
+	return k.ID < other.ID
+}
+
+// startKey validates the page arguments and returns the key to start after
+func startKey(pageSize int, cursor Cursor) (cursorKey, error) {
+	if pageSize <= 0 {
+		return cursorKey{}, errors.New("page size must be positive")
+	}
+	// Start before the first possible row when there is no cursor
+	if cursor == "" {
+		return cursorKey{BlockNumber: -1}, nil
+	}
+	return cursor.decode()
+}
+
+// newPage returns the first pageSize rows as a page, with a next cursor if there are more
+func newPage(rows []keyedTransfer, pageSize int) *Page {
+	page := &Page{Records: make([]*Config.Transfer, 0, min(len(rows), pageSize))}
+	for i, row := range rows {
+		if i == pageSize {
+			// The extra row only tells us there is more
+			last := rows[pageSize-1].key
+			page.Next = NewCursor(last.BlockNumber, last.TxBlockIndex, last.ID)
+			break
+		}
+		page.Records = append(page.Records, row.record)
+	}
+	return page
+}
+
+// queryAddressPage runs the keyset query for one page of column = address
+// One extra row is fetched to know whether a next page exists
+func (t *TableOps) queryAddressPage(ctx context.Context, column, address string, pageSize int, cursor Cursor) (*Page, error) {
+	key, err := startKey(pageSize, cursor)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := t.fetchAfter(ctx, column, address, key, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(rows, pageSize), nil
+}
+
+// fetchAfter returns up to limit rows with column = address that sort after key, in chain order
+func (t *TableOps) fetchAfter(ctx context.Context, column, address string, key cursorKey, limit int) ([]keyedTransfer, error) {
+	// blockNumber >= ? bounds the index scan; the OR chain skips rows of that block already returned
+	// ORDER BY follows the composite index, then id so rows sharing a position keep the cursor's order
+	queryPageSQL := fmt.Sprintf(
+		`SELECT id, %s FROM %s
+		WHERE %s = ? AND blockNumber >= ?
+		AND (blockNumber > ? OR (blockNumber = ? AND (txBlockIndex > ? OR (txBlockIndex = ? AND id > ?))))
+		ORDER BY %s, blockNumber, txBlockIndex, id
+		LIMIT ?`,
+		transferColumns, t.from(), column, column,
+	)
+
+	rows, err := t.DB.QueryContext(ctx, queryPageSQL,
+		address, key.BlockNumber,
+		key.BlockNumber, key.BlockNumber, key.TxBlockIndex, key.TxBlockIndex, key.ID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query page: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]keyedTransfer, 0, limit)
+	for rows.Next() {
+		var id int64
+		record, err := scanTransfer(rows, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		result = append(result, keyedTransfer{
+			key:    cursorKey{BlockNumber: record.BlockNumber, TxBlockIndex: record.TxBlockIndex, ID: id},
+			record: record,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,386 @@
+package IMMUSQL
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"DBTests/IMMUDB"
+)
+
+/*
+- Proof bundles: everything needed to prove, without access to immudb, that a transfer is in the
+  ledger at a given database state
+- A bundle holds the row as selected, the server's VerifiableSQLGet answer for it (the stored row,
+  the header of the tx that wrote it, the inclusion proof of the row in that tx and the dual proof
+  linking that tx to the state) and the state itself, signed when the server has a signing key
+- The state is the database state when the bundle was made, verified against the exporter's
+  trusted state first (see IMMUDB/state.go), so the exporter never hands out a proof to a history
+  it doesn't trust
+- VerifyProofBundle redoes the checks of the client's VerifyRow offline. The proofs tie the row
+  to State; whoever checks a bundle must trust State itself, by comparing it with a state they got
+  from the server, or through the signature with the server's public key
+- The readable fields (Record, Tx, State) are checked against the proofs too, so they can be quoted
+*/
+
+// ProofBundleVersion is the format version written into bundles
+const ProofBundleVersion = 1
+
+// ProofBundle is a self-contained proof that a transfer is in the ledger at State
+type ProofBundle struct {
+	Version    int             `json:"version"`
+	Database   string          `json:"database"`
+	Table      string          `json:"table"`
+	Record     ExportedRecord  `json:"record"`
+	Tx         ProofTx         `json:"tx"`    // the tx that wrote the row
+	State      ProofState      `json:"state"` // the state the row is proven against
+	ExportedAt time.Time       `json:"exportedAt"`
+	Row        json.RawMessage `json:"row"`   // schema.Row as selected, protobuf JSON
+	Entry      json.RawMessage `json:"entry"` // schema.VerifiableSQLEntry, protobuf JSON
+}
+
+// ProofTx describes the tx that wrote the row
+type ProofTx struct {
+	ID        uint64    `json:"id"`
+	Timestamp time.Time `json:"timestamp"` // commit time
+	Alh       string    `json:"alh"`       // hex accumulated hash of the tx
+}
+
+// ProofState is a database state: a tx id and its hex accumulated hash
+type ProofState struct {
+	TxID   uint64 `json:"txId"`
+	TxHash string `json:"txHash"`
+	Signed bool   `json:"signed"` // the entry carries the server's signature of the state
+}
+
+// ProofCheck is the outcome of VerifyProofBundle
+type ProofCheck struct {
+	State             ProofState
+	SignatureVerified bool // the state's signature was checked with the given public key
+}
+
+// ExportProof returns a proof bundle for the transfer with the hash, nil, nil when there is none
+// The bundle proves the row against the current state, which is verified against the trusted state first
+func (t *TableOps) ExportProof(ctx context.Context, transactionHash string) (*ProofBundle, error) {
+	var bundle *ProofBundle
+	querySQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE transactionHash = @value",
+		transferColumns, t.table,
+	)
+	err := IMMUDB.WithTrustedClient(ctx, t.Profile, t.DB, func(c client.ImmuClient) error {
+		result, err := c.SQLQuery(ctx, querySQL, map[string]interface{}{"value": transactionHash}, false)
+		if err != nil {
+			return fmt.Errorf("failed to query record: %w", err)
+		}
+		if len(result.Rows) == 0 {
+			return nil
+		}
+		row := result.Rows[0]
+		id, _, err := rowTransfer(row)
+		if err != nil {
+			return err
+		}
+
+		// The state to prove against, consistent with the trusted one (and trusted from now on)
+		stateTx, _, err := verifiedState(ctx, c)
+		if err != nil {
+			return err
+		}
+		entry, err := provenEntry(ctx, c, t.table, id, 0, stateTx)
+		if err != nil {
+			return err
+		}
+
+		bundle = &ProofBundle{
+			Version:    ProofBundleVersion,
+			Database:   t.Profile.Database,
+			Table:      t.table,
+			ExportedAt: time.Now().UTC(),
+		}
+		if bundle.Row, err = protojson.Marshal(row); err != nil {
+			return err
+		}
+		if bundle.Entry, err = protojson.Marshal(entry); err != nil {
+			return err
+		}
+		proven, err := bundle.prove()
+		if err != nil {
+			return err
+		}
+		bundle.Record, bundle.Tx, bundle.State = proven.record, proven.tx, proven.state
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// verifiedState returns the server's current state, once verified against the client's trusted state
+// (which it then becomes): its tx id and accumulated hash
+func verifiedState(ctx context.Context, c client.ImmuClient) (uint64, [sha256.Size]byte, error) {
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return 0, [sha256.Size]byte{}, fmt.Errorf("failed to get current state: %w", err)
+	}
+	tx, err := c.VerifiedTxByID(ctx, state.TxId)
+	if err != nil {
+		if errors.Is(err, store.ErrCorruptedData) || errors.Is(err, IMMUDB.ErrTamperAlert) {
+			return 0, [sha256.Size]byte{}, fmt.Errorf("%w: state at tx %d: %v", ErrVerification, state.TxId, err)
+		}
+		return 0, [sha256.Size]byte{}, fmt.Errorf("failed to verify state at tx %d: %w", state.TxId, err)
+	}
+	return state.TxId, schema.TxHeaderFromProto(tx.Header).Alh(), nil
+}
+
+// provenEntry returns the row with primary key id as written at atTx (0 for the current row), with the
+// proofs linking it to the state at stateTx
+// The client fetches missing linear advance proofs while verifying; they are filled in here, so the
+// entry verifies offline with verifyEntry
+func provenEntry(ctx context.Context, c client.ImmuClient, table string, id int64, atTx, stateTx uint64) (*schema.VerifiableSQLEntry, error) {
+	entry, err := c.GetServiceClient().VerifiableSQLGet(ctx, &schema.VerifiableSQLGetRequest{
+		SqlGetRequest: &schema.SQLGetRequest{Table: table, PkValues: []*schema.SQLValue{{Value: &schema.SQLValue_N{N: id}}}, AtTx: atTx},
+		ProveSinceTx:  stateTx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proofs: %w", err)
+	}
+	if entry.VerifiableTx == nil || entry.VerifiableTx.DualProof == nil || entry.SqlEntry == nil {
+		return nil, fmt.Errorf("%w: incomplete proofs", ErrVerification)
+	}
+	dualProof := schema.DualProofFromProto(entry.VerifiableTx.DualProof)
+	source, target := entry.SqlEntry.Tx, stateTx
+	if target < source {
+		source, target = target, source
+	}
+	if err := schema.FillMissingLinearAdvanceProof(ctx, dualProof, source, target, c.GetServiceClient()); err != nil {
+		return nil, fmt.Errorf("failed to get linear advance proof: %w", err)
+	}
+	entry.VerifiableTx.DualProof = schema.DualProofToProto(dualProof)
+	return entry, nil
+}
+
+// VerifyProofBundle checks a bundle offline: the row against its proofs, and the readable fields against both
+// With a public key, the state must carry a valid signature by it
+// Returns ErrVerification when anything doesn't match
+func VerifyProofBundle(bundle *ProofBundle, publicKey *ecdsa.PublicKey) (*ProofCheck, error) {
+	if bundle.Version != ProofBundleVersion {
+		return nil, fmt.Errorf("unsupported proof bundle version %d", bundle.Version)
+	}
+	proven, err := bundle.prove()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case proven.record != bundle.Record:
+		return nil, fmt.Errorf("%w: record does not match the proven row", ErrVerification)
+	case !proven.tx.Timestamp.Equal(bundle.Tx.Timestamp) || proven.tx.ID != bundle.Tx.ID || proven.tx.Alh != bundle.Tx.Alh:
+		return nil, fmt.Errorf("%w: tx does not match the proven tx %d", ErrVerification, proven.tx.ID)
+	case proven.state != bundle.State:
+		return nil, fmt.Errorf("%w: state does not match the proven state at tx %d", ErrVerification, proven.state.TxID)
+	}
+
+	check := &ProofCheck{State: proven.state}
+	if publicKey != nil {
+		if proven.signed.Signature == nil {
+			return check, fmt.Errorf("%w: state is not signed", ErrVerification)
+		}
+		if err := proven.signed.CheckSignature(publicKey); err != nil {
+			return check, fmt.Errorf("%w: state signature: %v", ErrVerification, err)
+		}
+		check.SignatureVerified = true
+	}
+	return check, nil
+}
+
+// provenBundle is what the proofs of a bundle establish
+type provenBundle struct {
+	record ExportedRecord
+	tx     ProofTx
+	state  ProofState
+	signed *schema.ImmutableState
+}
+
+// prove verifies the row and entry of the bundle, as client.VerifyRow does with the bundle's state as the trusted one
+func (b *ProofBundle) prove() (*provenBundle, error) {
+	row := &schema.Row{}
+	if err := protojson.Unmarshal(b.Row, row); err != nil {
+		return nil, fmt.Errorf("failed to parse the bundle row: %w", err)
+	}
+	entry := &schema.VerifiableSQLEntry{}
+	if err := protojson.Unmarshal(b.Entry, entry); err != nil {
+		return nil, fmt.Errorf("failed to parse the bundle entry: %w", err)
+	}
+	if len(row.Columns) == 0 || len(row.Columns) != len(row.Values) {
+		return nil, fmt.Errorf("%w: malformed row", ErrVerification)
+	}
+	id, record, err := rowTransfer(row)
+	if err != nil {
+		return nil, err
+	}
+	if entry.SqlEntry == nil {
+		return nil, fmt.Errorf("%w: incomplete proofs", ErrVerification)
+	}
+	stored, err := decodeStoredRow(entry.SqlEntry.Value, entry.ColTypesById, entry.MaxColId)
+	if err != nil {
+		return nil, err
+	}
+	if err := matchStoredRow(row, stored, entry.ColIdsByName); err != nil {
+		return nil, err
+	}
+	txHeader, targetID, targetAlh, err := verifyEntry(entry, id)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &schema.ImmutableState{Db: b.Database, TxId: targetID, TxHash: targetAlh[:], Signature: entry.VerifiableTx.Signature}
+	txAlh := txHeader.Alh()
+	return &provenBundle{
+		record: ExportedRecord{ID: id, Transfer: *record},
+		tx:     ProofTx{ID: txHeader.ID, Timestamp: time.Unix(txHeader.Ts, 0).UTC(), Alh: hex.EncodeToString(txAlh[:])},
+		state:  ProofState{TxID: targetID, TxHash: hex.EncodeToString(targetAlh[:]), Signed: signed.Signature != nil},
+		signed: signed,
+	}, nil
+}
+
+// verifyEntry checks that entry is stored under primary key id in its tx (the inclusion proof) and that
+// the tx is consistent with the target of the dual proof; returns the entry's tx header and that target
+// The target is only as good as whatever it is compared with: a trusted state, or a signature
+func verifyEntry(entry *schema.VerifiableSQLEntry, id int64) (*store.TxHeader, uint64, [sha256.Size]byte, error) {
+	var none [sha256.Size]byte
+	corrupted := func(what string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(what, args...))
+	}
+	if entry.SqlEntry == nil || entry.VerifiableTx == nil || entry.VerifiableTx.Tx == nil ||
+		entry.VerifiableTx.Tx.Header == nil || entry.VerifiableTx.DualProof == nil || entry.InclusionProof == nil {
+		return nil, 0, none, corrupted("incomplete proofs")
+	}
+
+	// The key the row is stored under
+	if len(entry.PKIDs) != 1 {
+		return nil, 0, none, corrupted("got %d primary key columns, want 1", len(entry.PKIDs))
+	}
+	pkID := entry.PKIDs[0]
+	pkType, okType := entry.ColTypesById[pkID]
+	pkLen, okLen := entry.ColLenById[pkID]
+	if !okType || !okLen {
+		return nil, 0, none, corrupted("primary key column %d not described", pkID)
+	}
+	pkVal, _, err := sql.EncodeRawValueAsKey(id, pkType, int(pkLen))
+	if err != nil {
+		return nil, 0, none, corrupted("primary key: %v", err)
+	}
+	pkKey := sql.MapKey([]byte{client.SQLPrefix}, sql.RowPrefix,
+		sql.EncodeID(entry.DatabaseId), sql.EncodeID(entry.TableId), sql.EncodeID(sql.PKIndexID), pkVal)
+
+	// The row is in its tx, and its tx is consistent with the target
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(entry.VerifiableTx.Tx.Header.Version))
+	if err != nil {
+		return nil, 0, none, corrupted("%v", err)
+	}
+	dualProof := schema.DualProofFromProto(entry.VerifiableTx.DualProof)
+	vTx := entry.SqlEntry.Tx
+	var txHeader *store.TxHeader
+	var eh, sourceAlh, targetAlh [sha256.Size]byte
+	var sourceID, targetID uint64
+	switch {
+	case dualProof.TargetTxHeader.ID == vTx:
+		// The target is not after the row's tx: the row's tx is the target
+		txHeader = dualProof.TargetTxHeader
+		eh = schema.DigestFromProto(entry.VerifiableTx.DualProof.TargetTxHeader.EH)
+		sourceID, sourceAlh = dualProof.SourceTxHeader.ID, dualProof.SourceTxHeader.Alh()
+		targetID, targetAlh = vTx, dualProof.TargetTxHeader.Alh()
+	case dualProof.SourceTxHeader.ID == vTx:
+		txHeader = dualProof.SourceTxHeader
+		eh = schema.DigestFromProto(entry.VerifiableTx.DualProof.SourceTxHeader.EH)
+		sourceID, sourceAlh = vTx, dualProof.SourceTxHeader.Alh()
+		targetID, targetAlh = dualProof.TargetTxHeader.ID, dualProof.TargetTxHeader.Alh()
+	default:
+		return nil, 0, none, corrupted("dual proof does not include tx %d", vTx)
+	}
+	// A delete is stored with its metadata, which is part of the entry's digest
+	e := &store.EntrySpec{Key: pkKey, Metadata: schema.KVMetadataFromProto(entry.SqlEntry.Metadata), Value: entry.SqlEntry.Value}
+	if !store.VerifyInclusion(schema.InclusionProofFromProto(entry.InclusionProof), entrySpecDigest(e), eh) {
+		return nil, 0, none, corrupted("row is not included in tx %d", vTx)
+	}
+	if !store.VerifyDualProof(dualProof, sourceID, targetID, sourceAlh, targetAlh) {
+		return nil, 0, none, corrupted("tx %d is not consistent with tx %d", sourceID, targetID)
+	}
+	return txHeader, targetID, targetAlh, nil
+}
+
+// decodeStoredRow decodes a stored row value into its values by column id, as the client does in VerifyRow
+func decodeStoredRow(encoded []byte, colTypes map[uint32]sql.SQLValueType, maxColID uint32) (map[uint32]*schema.SQLValue, error) {
+	if len(encoded) < sql.EncLenLen {
+		return nil, fmt.Errorf("%w: stored row too short", ErrVerification)
+	}
+	count := binary.BigEndian.Uint32(encoded)
+	off := sql.EncLenLen
+	values := make(map[uint32]*schema.SQLValue, count)
+	for range count {
+		if len(encoded) < off+sql.EncIDLen {
+			return nil, fmt.Errorf("%w: stored row too short", ErrVerification)
+		}
+		colID := binary.BigEndian.Uint32(encoded[off:])
+		off += sql.EncIDLen
+
+		colType, ok := colTypes[colID]
+		if !ok {
+			// A dropped column
+			if colID > maxColID {
+				return nil, fmt.Errorf("%w: unknown column %d in stored row", ErrVerification, colID)
+			}
+			n, voff, err := sql.DecodeValueLength(encoded[off:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: stored row: %v", ErrVerification, err)
+			}
+			off += n + voff
+			continue
+		}
+		val, n, err := sql.DecodeValue(encoded[off:], colType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: stored row: %v", ErrVerification, err)
+		}
+		values[colID] = schema.TypedValueToRowValue(val)
+		off += n
+	}
+	return values, nil
+}
+
+// matchStoredRow checks every value of row against the stored row
+func matchStoredRow(row *schema.Row, stored map[uint32]*schema.SQLValue, colIdsByName map[string]uint32) error {
+	for i, name := range row.Columns {
+		colID, ok := colIdsByName[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown column %s", ErrVerification, name)
+		}
+		val := row.Values[i]
+		if val == nil || val.Value == nil {
+			return fmt.Errorf("%w: no value for column %s", ErrVerification, name)
+		}
+		storedVal, ok := stored[colID]
+		if !ok {
+			if _, isNull := val.Value.(*schema.SQLValue_Null); isNull {
+				continue
+			}
+			return fmt.Errorf("%w: column %s is not stored", ErrVerification, name)
+		}
+		equal, err := val.Value.(schema.SqlValue).Equal(storedVal.Value.(schema.SqlValue))
+		if err != nil || !equal {
+			return fmt.Errorf("%w: column %s does not match the stored row", ErrVerification, name)
+		}
+	}
+	return nil
+}
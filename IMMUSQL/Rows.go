@@ -0,0 +1,113 @@
+package IMMUSQL
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"time"
+
+	"DBTests/Config"
+)
+
+/*
+- Shared row mapping for the transfer table
+- Every query returning transfers selects transferColumns (optionally preceded by id) and maps
+  each row with scanTransfer
+- Multi-row queries are exposed as iter.Seq2 streams: rows are scanned one at a time, so any
+  number of them can be processed in constant memory, and breaking out of the range loop
+  stops the query early. The QueryRecords* methods simply collect a stream into a slice
+
+Usage:
+
+	for record, err := range ops.RecordsByFrom(ctx, addr) {
+		if err != nil {
+			return err
+		}
+		...
+	}
+*/
+
+// transferColumns are the columns mapped into a Config.Transfer, in scan order
+const transferColumns = "transactionHash, fromAddr, toAddr, blockNumber, blockHash, txBlockIndex, ts"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTransfer maps one row of transferColumns into a Transfer
+// extra receives any columns selected before transferColumns (e.g. &id); a NULL toAddr maps to an empty To
+func scanTransfer(row rowScanner, extra ...any) (*Config.Transfer, error) {
+	var record Config.Transfer
+	var to sql.NullString
+	var ts time.Time
+	dest := append(extra,
+		&record.TransactionHash,
+		&record.From,
+		&to,
+		&record.BlockNumber,
+		&record.BlockHash,
+		&record.TxBlockIndex,
+		&ts,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	record.To = to.String
+	record.Timestamp = ts.Unix() // Convert time.Time to Unix timestamp
+	return &record, nil
+}
+
+// streamTransfers runs a SELECT of transferColumns when ranged over and yields one record per row
+// A failure is yielded once as (nil, err) and ends the stream
+func (t *TableOps) streamTransfers(ctx context.Context, querySQL string, args ...any) iter.Seq2[*Config.Transfer, error] {
+	return func(yield func(*Config.Transfer, error) bool) {
+		rows, err := t.DB.QueryContext(ctx, querySQL, args...)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to query records: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			record, err := scanTransfer(rows)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to scan record: %w", err))
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("error iterating rows: %w", err))
+		}
+	}
+}
+
+// collectTransfers drains a stream into a slice
+func collectTransfers(records iter.Seq2[*Config.Transfer, error]) ([]*Config.Transfer, error) {
+	var collected []*Config.Transfer
+	for record, err := range records {
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, record)
+	}
+	return collected, nil
+}
+
+// ForEach calls fn for every record of a stream, stopping at the first error from the stream or fn
+func ForEach(records iter.Seq2[*Config.Transfer, error], fn func(*Config.Transfer) error) error {
+	for record, err := range records {
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package IMMUSQL
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+)
+
+/*
+- Verified reads: rows are selected through the native client and each one is checked with
+  VerifyRow before it is returned
+- VerifyRow fetches the row's proofs from the server: an inclusion proof (the row is in the
+  transaction that wrote it) and a dual proof (that transaction is consistent with the state the
+  client trusts). The trusted state is the profile's IMMUDB.StateStore, kept between runs, and
+  moves forward with every successful verification
+- A proof mismatch returns ErrVerification: the server's data or history has been altered, or the
+  server is not the one the trusted state came from. Never retry it away
+- Each verified row is also checked against the lookup value (a real row for another hash or
+  address is ErrVerification). What is proven is only that every returned row is in the ledger and
+  matches: not that the server returned all matching rows, nor that a missing record doesn't exist
+- Every row costs one extra VerifiableSQLGet round trip, so verified lookups returning many rows
+  cost many round trips; the verify scenario measures it against the plain reads
+
+Usage:
+
+	record, err := ops.VerifiedQueryRecord(ctx, hash)
+	if errors.Is(err, IMMUSQL.ErrVerification) {
+		// tamper alert
+	}
+*/
+
+// ErrVerification is returned by the Verified* reads when a row does not match its proofs
+var ErrVerification = errors.New("verification failed")
+
+// VerifiedQueryRecord is QueryRecord with the row verified against the trusted state
+// Returns nil, nil when the server returns no record for the hash; that absence is not proven
+func (t *TableOps) VerifiedQueryRecord(ctx context.Context, transactionHash string) (*Config.Transfer, error) {
+	querySQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE transactionHash = @value",
+		transferColumns, t.table,
+	)
+	records, err := t.verifiedQuery(ctx, querySQL, transactionHash, func(r *Config.Transfer) bool {
+		return r.TransactionHash == transactionHash
+	})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+// VerifiedQueryRecordsByFrom is QueryRecordsByFrom with every row verified against the trusted state
+// Rows the server leaves out are not detected
+func (t *TableOps) VerifiedQueryRecordsByFrom(ctx context.Context, fromAddress string) ([]*Config.Transfer, error) {
+	querySQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE fromAddr = @value",
+		transferColumns, t.table,
+	)
+	return t.verifiedQuery(ctx, querySQL, fromAddress, func(r *Config.Transfer) bool { return r.From == fromAddress })
+}
+
+// VerifiedQueryRecordsByTo is QueryRecordsByTo with every row verified against the trusted state
+// Rows the server leaves out are not detected
+func (t *TableOps) VerifiedQueryRecordsByTo(ctx context.Context, toAddress string) ([]*Config.Transfer, error) {
+	querySQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE toAddr = @value",
+		transferColumns, t.table,
+	)
+	return t.verifiedQuery(ctx, querySQL, toAddress, func(r *Config.Transfer) bool { return r.To == toAddress })
+}
+
+// VerifiedQueryRecordsByBlockNumber is QueryRecordsByBlockNumber with every row verified against the trusted state
+// Rows the server leaves out are not detected
+func (t *TableOps) VerifiedQueryRecordsByBlockNumber(ctx context.Context, blockNumber int) ([]*Config.Transfer, error) {
+	querySQL := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE blockNumber = @value",
+		transferColumns, t.table,
+	)
+	return t.verifiedQuery(ctx, querySQL, blockNumber, func(r *Config.Transfer) bool { return r.BlockNumber == blockNumber })
+}
+
+// verifiedQuery runs a SELECT of id and transferColumns with one @value parameter and verifies every row
+// All rows are read and verified on one connection, against the profile's trusted state; a verified row
+// for which match (the query's predicate) is false is ErrVerification
+func (t *TableOps) verifiedQuery(ctx context.Context, querySQL string, value any, match func(*Config.Transfer) bool) ([]*Config.Transfer, error) {
+	var records []*Config.Transfer
+	err := IMMUDB.WithTrustedClient(ctx, t.Profile, t.DB, func(c client.ImmuClient) error {
+		result, err := c.SQLQuery(ctx, querySQL, map[string]interface{}{"value": value}, false)
+		if err != nil {
+			return fmt.Errorf("failed to query records: %w", err)
+		}
+		for _, row := range result.Rows {
+			id, record, err := rowTransfer(row)
+			if err != nil {
+				return err
+			}
+			if err := t.verifyRow(ctx, c, row, id); err != nil {
+				return fmt.Errorf("record %s (id %d): %w", record.TransactionHash, id, err)
+			}
+			if !match(record) {
+				return fmt.Errorf("record %s (id %d): %w: does not match %v", record.TransactionHash, id, ErrVerification, value)
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// verifyRow checks row (primary key id) against its proofs and the client's trusted state
+func (t *TableOps) verifyRow(ctx context.Context, c client.ImmuClient, row *schema.Row, id int64) error {
+	pk := []*schema.SQLValue{{Value: &schema.SQLValue_N{N: id}}}
+	if err := c.VerifyRow(ctx, row, t.table, pk); err != nil {
+		if errors.Is(err, store.ErrCorruptedData) || errors.Is(err, IMMUDB.ErrTamperAlert) {
+			return fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		return fmt.Errorf("failed to verify row: %w", err)
+	}
+	return nil
+}
+
+// rowTransfer maps a native client row of id and transferColumns into its id and Transfer
+// A NULL toAddr maps to an empty To, as in scanTransfer
+func rowTransfer(row *schema.Row) (int64, *Config.Transfer, error) {
+	if len(row.Values) != 8 {
+		return 0, nil, fmt.Errorf("failed to scan record: got %d columns, want 8", len(row.Values))
+	}
+	v := row.Values
+	record := &Config.Transfer{
+		TransactionHash: v[1].GetS(),
+		From:            v[2].GetS(),
+		To:              v[3].GetS(), // "" for NULL
+		BlockNumber:     int(v[4].GetN()),
+		BlockHash:       v[5].GetS(),
+		TxBlockIndex:    int(v[6].GetN()),
+		Timestamp:       time.UnixMicro(v[7].GetTs()).Unix(),
+	}
+	return v[0].GetN(), record, nil
+}
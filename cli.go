@@ -0,0 +1,874 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Non-interactive CLI: every scenario of the interactive menu is a subcommand, and every field of
+  its config struct has a flag, so jobs can script any run without a TTY
+- Global flags (connection, table, see Config.Load) come before the command, command flags after it:
+
+	go run . -backend embedded index -transactions 20000 -reads 500 -hash-ratio 0.5
+	go run . help index
+	go run . test -transactions 10000 -json out/test.json -csv out/test.csv
+
+- Exit codes: 0 success, 1 the scenario failed, 2 bad command or flags
+*/
+
+// command is one simulator subcommand
+// setup registers the command's flags on fs and returns the function that runs it
+// with the positional arguments left after the flags
+type command struct {
+	name    string
+	aliases []string
+	args    string // positional arguments, for usage
+	summary string
+	setup   func(fs *flag.FlagSet) func(args []string) error
+}
+
+// errUsage marks errors caused by a bad command line rather than a failed run
+var errUsage = errors.New("usage error")
+
+// commands lists the subcommands in the order help shows them
+var commands = []command{
+	{
+		name:    "query",
+		aliases: []string{"state", "status"},
+		summary: "Query table state",
+		setup:   noFlags(queryTableState),
+	},
+	{
+		name:    "stats",
+		summary: "Print table statistics, now or as of a past tx or time",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			var asOf immusql.AsOf
+			fs.Func("as-of", "read the table as of a tx id (123 or tx:123) or a commit time (RFC 3339 or \"2006-01-02 15:04:05\")", func(s string) (err error) {
+				asOf, err = parseAsOf(s)
+				return err
+			})
+			return func([]string) error {
+				runStats(asOf)
+				return nil
+			}
+		},
+	},
+	{
+		name:    "test",
+		aliases: []string{"perf", "performance"},
+		summary: "Run performance test",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultTestConfig()
+			testConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runPerformanceTest(config))
+			}
+		},
+	},
+	{
+		name:    "index",
+		aliases: []string{"indexperf"},
+		summary: "Run index performance test (realistic block-based workload)",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultIndexPerformanceConfig()
+			indexConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runIndexPerformanceTest(config))
+			}
+		},
+	},
+	{
+		name:    "benchmark",
+		aliases: []string{"bench", "compare"},
+		summary: "Benchmark with indexes vs without indexes (drops and recreates both tables)",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultBenchmarkConfig()
+			testConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runIndexBenchmarkComparison(config, false))
+			}
+		},
+	},
+	{
+		name:    "load",
+		summary: "Run the read mix with concurrent clients, optionally sweeping the client count",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultLoadConfig()
+			loadConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runConcurrentLoadTest(config))
+			}
+		},
+	},
+	{
+		name:    "mixed",
+		summary: "Append blocks at a fixed block time while readers run the read mix; read latency per window",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultMixedWorkloadConfig()
+			mixedConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runMixedWorkload(config))
+			}
+		},
+	},
+	{
+		name:    "verify",
+		summary: "Measure the latency of cryptographically verified reads against plain ones",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultVerifyConfig()
+			verifyConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runVerifyBenchmark(config))
+			}
+		},
+	},
+	{
+		name:    "timetravel",
+		summary: "Measure the latency of reads as of past transactions against current reads, while blocks are appended",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultTimeTravelConfig()
+			timeTravelConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return output.write(runTimeTravelBenchmark(config))
+			}
+		},
+	},
+	{
+		name:    "trust",
+		summary: "Check the server against the trusted state kept between runs; -forget drops it after a deliberate reset",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			forget := fs.Bool("forget", false, "forget the trusted state of the database instead of checking it")
+			return func([]string) error {
+				return runTrust(*forget)
+			}
+		},
+	},
+	{
+		name:    "proof",
+		args:    "transactionHash",
+		summary: "Write a self-contained inclusion proof bundle of one transfer, for offline verification",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			out := fs.String("o", "", "bundle file (default <transactionHash>.proof.json)")
+			return func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("%w: need exactly one transaction hash", errUsage)
+				}
+				return runProof(args[0], *out)
+			}
+		},
+	},
+	{
+		name:    "verify-proof",
+		args:    "file",
+		summary: "Check a proof bundle offline, without connecting to immudb",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			publicKey := fs.String("public-key", "", "PEM public key of the server's signing key; the bundle's state must be signed by it")
+			return func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("%w: need exactly one proof bundle", errUsage)
+				}
+				return runVerifyProof(args[0], *publicKey)
+			}
+		},
+	},
+	{
+		name:    "history",
+		args:    "transactionHash",
+		summary: "List every revision of a transfer's row with its tx and commit time, the changes and any delete",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			out := fs.String("o", "", "also write the history as JSON to this file")
+			removed := fs.Bool("removed", false, "also list rows that no longer carry the hash, deleted or updated to another one (scans the table's whole history)")
+			return func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("%w: need exactly one transaction hash", errUsage)
+				}
+				return runHistory(args[0], *out, *removed)
+			}
+		},
+	},
+	{
+		name:    "import",
+		args:    "file",
+		summary: "Import transfers from a JSONL or CSV file, validating against the schema; -resume continues an interrupted import",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultImportConfig()
+			importConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("%w: need exactly one file to import", errUsage)
+				}
+				config.Path = args[0]
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				report, err := runImport(config)
+				if report != nil {
+					if err := output.write(report); err != nil {
+						return err
+					}
+				}
+				return err
+			}
+		},
+	},
+	{
+		name:    "export",
+		args:    "file",
+		summary: "Export the table, or a block/time range, to JSONL or CSV in id order, from one consistent snapshot",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultExportConfig()
+			exportConfigFlags(fs, &config)
+			return func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("%w: need exactly one file to export to", errUsage)
+				}
+				config.Path = args[0]
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return runExport(config)
+			}
+		},
+	},
+	{
+		name:    "compare-reports",
+		args:    "baseline.json candidate.json...",
+		summary: "Compare saved JSON reports against a baseline; exits 1 on regressions beyond the thresholds",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			th := DefaultCompareThresholds()
+			fs.Float64Var(&th.P50, "p50-threshold", th.P50, "max allowed p50 latency increase, percent")
+			fs.Float64Var(&th.P95, "p95-threshold", th.P95, "max allowed p95 latency increase, percent")
+			fs.Float64Var(&th.P99, "p99-threshold", th.P99, "max allowed p99 latency increase, percent")
+			fs.Float64Var(&th.InsertRate, "insert-threshold", th.InsertRate, "max allowed insert rate drop, percent")
+			fs.DurationVar(&th.MinLatencyDelta, "min-delta", th.MinLatencyDelta, "latency increases smaller than this are never regressions")
+			return func(paths []string) error {
+				if len(paths) < 2 {
+					return fmt.Errorf("%w: need a baseline and at least one candidate report", errUsage)
+				}
+				regressions, err := runCompare(paths, th)
+				if err != nil {
+					return err
+				}
+				if regressions > 0 {
+					return fmt.Errorf("%d regression(s)", regressions)
+				}
+				return nil
+			}
+		},
+	},
+	{
+		name:    "compareorderby",
+		summary: "Compare query times with and without ORDER BY on the indexed column",
+		setup:   noFlags(runCompareOrderByTest),
+	},
+	{
+		name:    "add",
+		aliases: []string{"addtxns"},
+		summary: "Append generated block-based transactions to the table",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultAddTxnsConfig()
+			addTxnsFlags(fs, &config)
+			return func([]string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				return AddtransactionsToDB(config)
+			}
+		},
+	},
+	{
+		name:    "profiles",
+		args:    "[name...]",
+		summary: "Run the same workload against several profiles, side by side (all profiles if none given)",
+		setup: func(fs *flag.FlagSet) func([]string) error {
+			config := DefaultTestConfig()
+			testConfigFlags(fs, &config)
+			output := reportFlags(fs)
+			return func(names []string) error {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				for _, name := range names {
+					if _, err := appSettings.Resolve(name); err != nil {
+						return fmt.Errorf("%w: %v", errUsage, err)
+					}
+				}
+				return output.write(runProfileComparison(config, names))
+			}
+		},
+	},
+}
+
+// noFlags adapts a scenario without parameters to a command
+func noFlags(run func()) func(*flag.FlagSet) func([]string) error {
+	return func(*flag.FlagSet) func([]string) error {
+		return func([]string) error {
+			run()
+			return nil
+		}
+	}
+}
+
+// findCommand returns the command with the given name or alias
+func findCommand(name string) (*command, bool) {
+	name = strings.ToLower(name)
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i], true
+		}
+		for _, alias := range commands[i].aliases {
+			if alias == name {
+				return &commands[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// newFlagSet returns the flag set of c with its usage message
+func (c *command) newFlagSet() (*flag.FlagSet, func([]string) error) {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	run := c.setup(fs)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: go run . [global flags] %s\n\n", strings.TrimSpace(c.name+" [flags] "+c.args))
+		fmt.Fprintf(out, "%s\n", c.summary)
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(out, "Aliases: %s\n", strings.Join(c.aliases, ", "))
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs, run
+}
+
+// runCommand runs the subcommand named by args[0] with the rest of args and returns the exit code
+func runCommand(args []string) int {
+	name := strings.ToLower(args[0])
+	switch name {
+	case "help", "-h", "--help":
+		return runHelp(args[1:])
+	}
+
+	c, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Use 'help' to see available commands")
+		return 2
+	}
+
+	fs, run := c.newFlagSet()
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if c.args == "" && fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "%s: unexpected arguments: %s\n", c.name, strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	if err := run(fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// runHelp prints the command list, or the flags of one command
+func runHelp(args []string) int {
+	if len(args) > 0 {
+		c, ok := findCommand(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+			return 2
+		}
+		fs, _ := c.newFlagSet()
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return 0
+	}
+
+	fmt.Println("Usage: go run . [global flags] [command] [command flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Printf("  %-24s - %s\n", "(none)", "Interactive mode")
+	for _, c := range commands {
+		fmt.Printf("  %-24s - %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Printf("  %-24s - %s\n", "help [command]", "Show this help, or the flags of a command")
+	fmt.Println()
+	fmt.Println("Global flags (override IMMUDB_* env vars, which override the config file):")
+	fmt.Println("  -config <file>    - YAML or TOML config file (env IMMUDB_CONFIG)")
+	fmt.Println("  -profile <name>   - named connection profile from the config file (env IMMUDB_PROFILE)")
+	fmt.Println("  -backend <mode>   - remote (default) or embedded: run immudb in-process, no server needed (env IMMUDB_BACKEND)")
+	fmt.Println("  -dir <path>       - data directory for the embedded backend, temp dir if empty (env IMMUDB_DIR)")
+	fmt.Println("  -trusted-state <f> - file keeping the last verified state between runs, or memory (env IMMUDB_TRUSTED_STATE)")
+	fmt.Println("  -host <host>      - immudb host (env IMMUDB_HOST)")
+	fmt.Println("  -port <port>      - immudb port (env IMMUDB_PORT)")
+	fmt.Println("  -user <user>      - immudb user (env IMMUDB_USER)")
+	fmt.Println("  -password <pass>  - immudb password (env IMMUDB_PASSWORD)")
+	fmt.Println("  -database <name>  - immudb database (env IMMUDB_DATABASE)")
+	fmt.Println("  -table <name>     - table name (env IMMUDB_TABLE)")
+	fmt.Println("  -server-timestamps - write the server's NOW() into ts instead of each record's timestamp (env IMMUDB_SERVER_TIMESTAMPS)")
+	fmt.Println("  -seed <n>         - test data seed; the same seed gives the same dataset and queries, 0 = random (env IMMUDB_SEED)")
+	fmt.Println("  -addresses <n>    - accounts in the generated data (env IMMUDB_ADDRESSES)")
+	fmt.Println("  -address-dist <d> - cycle (the 5 fixed test addresses), uniform, zipf or hotset (env IMMUDB_ADDRESS_DIST)")
+	fmt.Println("  -zipf-s, -hot-set, -hot-share - shape of the zipf and hotset distributions")
+	fmt.Println("  -contracts, -contract-calls, -creations - contract recipients and contract creations (NULL toAddr)")
+	return 0
+}
+
+// testConfigFlags registers a flag for every TestConfig field, defaulting to the current values
+func testConfigFlags(fs *flag.FlagSet, config *TestConfig) {
+	fs.IntVar(&config.TransactionCount, "transactions", config.TransactionCount, "number of transactions to generate and insert")
+	fs.IntVar(&config.BatchSize, "batch-size", config.BatchSize, "records per INSERT (0 = InsertRecords default)")
+	fs.IntVar(&config.QueryHashCount, "hash-queries", config.QueryHashCount, "number of transaction hash queries")
+	fs.IntVar(&config.QueryFromCount, "from-queries", config.QueryFromCount, "number of FROM address queries")
+	fs.IntVar(&config.QueryToCount, "to-queries", config.QueryToCount, "number of TO address queries")
+	fs.IntVar(&config.QueryBlockCount, "block-queries", config.QueryBlockCount, "number of block number queries")
+	fs.IntVar(&config.BlockNumberMin, "block-min", config.BlockNumberMin, "minimum block number of the generated data")
+	fs.IntVar(&config.BlockNumberMax, "block-max", config.BlockNumberMax, "maximum block number of the generated data")
+	fs.IntVar(&config.WarmupQueries, "warmup", config.WarmupQueries, "number of untimed warmup queries")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print detailed latency statistics")
+}
+
+// indexConfigFlags registers a flag for every IndexPerformanceConfig field, defaulting to the current values
+func indexConfigFlags(fs *flag.FlagSet, config *IndexPerformanceConfig) {
+	fs.IntVar(&config.TotalTransactions, "transactions", config.TotalTransactions, "number of transactions to generate and insert")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block (max 200)")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first generated block")
+	fs.IntVar(&config.RandomReadCount, "reads", config.RandomReadCount, "number of random read queries")
+	fs.Float64Var(&config.ReadHashRatio, "hash-ratio", config.ReadHashRatio, "share of transaction hash queries (0-1)")
+	fs.Float64Var(&config.ReadFromRatio, "from-ratio", config.ReadFromRatio, "share of FROM address queries (0-1)")
+	fs.Float64Var(&config.ReadToRatio, "to-ratio", config.ReadToRatio, "share of TO address queries (0-1)")
+	fs.Float64Var(&config.ReadBlockRatio, "block-ratio", config.ReadBlockRatio, "share of block number queries (0-1); the read ratios must add up to 1")
+	fs.Float64Var(&config.ReadBlockRangeRatio, "block-range-ratio", config.ReadBlockRangeRatio, "share of block range queries (0-1)")
+	fs.Float64Var(&config.ReadTimeRangeRatio, "time-range-ratio", config.ReadTimeRangeRatio, "share of time range queries (0-1)")
+	fs.Float64Var(&config.ReadAddrRangeRatio, "addr-range-ratio", config.ReadAddrRangeRatio, "share of FROM address + block range queries (0-1)")
+	fs.Float64Var(&config.ReadAddressRatio, "address-ratio", config.ReadAddressRatio, "share of combined in+out address queries (0-1)")
+	fs.IntVar(&config.BlockRangeSpan, "block-range-span", config.BlockRangeSpan, "blocks covered by a range query")
+	fs.Int64Var(&config.TimeRangeSpan, "time-range-span", config.TimeRangeSpan, "seconds covered by a time range query")
+	fs.IntVar(&config.PageQueryCount, "page-queries", config.PageQueryCount, "paginated address history queries per depth (0 = skip)")
+	fs.IntVar(&config.PageSize, "page-size", config.PageSize, "records per page for paginated queries")
+	fs.IntVar(&config.DeepPage, "deep-page", config.DeepPage, "page number measured as the deep page")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print detailed latency statistics")
+}
+
+// addTxnsFlags registers a flag for every AddTxnsConfig field, defaulting to the current values
+func addTxnsFlags(fs *flag.FlagSet, config *AddTxnsConfig) {
+	fs.IntVar(&config.TotalTransactions, "transactions", config.TotalTransactions, "number of transactions to generate and append")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first generated block")
+	fs.IntVar(&config.BatchSize, "batch-size", config.BatchSize, "records per INSERT (0 = InsertRecords default)")
+}
+
+// loadConfigFlags registers a flag for every LoadConfig field, defaulting to the current values
+func loadConfigFlags(fs *flag.FlagSet, config *LoadConfig) {
+	fs.IntVar(&config.TotalTransactions, "transactions", config.TotalTransactions, "transactions to seed the table with (0 = use the existing data)")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block when seeding (max 200)")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first seeded block")
+	fs.IntVar(&config.Clients, "clients", config.Clients, "number of concurrent clients")
+	fs.BoolVar(&config.Sweep, "sweep", config.Sweep, "run at 1, 2, 4 ... -max-clients clients to find the saturation point")
+	fs.IntVar(&config.MaxClients, "max-clients", config.MaxClients, "largest number of clients of the sweep")
+	fs.DurationVar(&config.Duration, "duration", config.Duration, "how long each concurrency level runs")
+	fs.IntVar(&config.QueriesPerClient, "queries", config.QueriesPerClient, "queries per client instead of -duration (0 = use -duration)")
+	fs.BoolVar(&config.OwnConnections, "own-connections", config.OwnConnections, "give every client its own connection instead of sharing the pool")
+	fs.IntVar(&config.MaxConns, "max-conns", config.MaxConns, "max open connections of the shared pool (0 = unlimited)")
+	fs.IntVar(&config.KeySampleSize, "key-sample", config.KeySampleSize, "records sampled from the table for query keys")
+	fs.Float64Var(&config.ReadHashRatio, "hash-ratio", config.ReadHashRatio, "share of transaction hash queries (0-1)")
+	fs.Float64Var(&config.ReadFromRatio, "from-ratio", config.ReadFromRatio, "share of FROM address first-page queries (0-1)")
+	fs.Float64Var(&config.ReadToRatio, "to-ratio", config.ReadToRatio, "share of TO address first-page queries (0-1); block queries take the rest")
+	fs.IntVar(&config.PageSize, "page-size", config.PageSize, "records per page for address queries")
+	fs.Float64Var(&config.Rate, "rate", config.Rate, "also run each level open-loop at this many queries/s, timed from the intended start (0 = closed loop only)")
+	fs.StringVar(&config.Arrivals, "arrivals", config.Arrivals, "open-loop arrivals: constant or poisson")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print per-query-type and per-client statistics")
+}
+
+// mixedConfigFlags registers a flag for every MixedWorkloadConfig field, defaulting to the current values
+func mixedConfigFlags(fs *flag.FlagSet, config *MixedWorkloadConfig) {
+	fs.IntVar(&config.InitialTransactions, "transactions", config.InitialTransactions, "transactions seeded before the run starts")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block, seeded and appended (max 200)")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first seeded block")
+	fs.DurationVar(&config.BlockTime, "block-time", config.BlockTime, "interval between appended blocks")
+	fs.IntVar(&config.Blocks, "blocks", config.Blocks, "blocks the writer appends during the run")
+	fs.IntVar(&config.WindowBlocks, "window-blocks", config.WindowBlocks, "blocks per reporting window")
+	fs.IntVar(&config.Readers, "readers", config.Readers, "number of concurrent reader clients")
+	fs.Float64Var(&config.ReadHashRatio, "hash-ratio", config.ReadHashRatio, "share of transaction hash queries (0-1)")
+	fs.Float64Var(&config.ReadFromRatio, "from-ratio", config.ReadFromRatio, "share of FROM address queries (0-1)")
+	fs.Float64Var(&config.ReadToRatio, "to-ratio", config.ReadToRatio, "share of TO address queries (0-1)")
+	fs.Float64Var(&config.ReadBlockRangeRatio, "block-range-ratio", config.ReadBlockRangeRatio, "share of block range queries (0-1)")
+	fs.Float64Var(&config.ReadTimeRangeRatio, "time-range-ratio", config.ReadTimeRangeRatio, "share of time range queries (0-1)")
+	fs.Float64Var(&config.ReadAddrRangeRatio, "addr-range-ratio", config.ReadAddrRangeRatio, "share of FROM address + block range queries (0-1)")
+	fs.Float64Var(&config.ReadAddressRatio, "address-ratio", config.ReadAddressRatio, "share of combined in+out address queries (0-1); block queries take the rest")
+	fs.IntVar(&config.BlockRangeSpan, "block-range-span", config.BlockRangeSpan, "blocks covered by a range query")
+	fs.Int64Var(&config.TimeRangeSpan, "time-range-span", config.TimeRangeSpan, "seconds covered by a time range query")
+	fs.IntVar(&config.PageSize, "page-size", config.PageSize, "records per page for address queries")
+	fs.IntVar(&config.KeySampleSize, "key-sample", config.KeySampleSize, "records sampled from the table for query addresses")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print per-query-type statistics of every window")
+}
+
+// verifyConfigFlags registers a flag for every VerifyConfig field, defaulting to the current values
+func verifyConfigFlags(fs *flag.FlagSet, config *VerifyConfig) {
+	fs.IntVar(&config.TotalTransactions, "transactions", config.TotalTransactions, "transactions to seed the table with (0 = use the existing data)")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block when seeding (max 200)")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first seeded block")
+	fs.IntVar(&config.Queries, "queries", config.Queries, "lookups per query type, each run plain and verified")
+	fs.IntVar(&config.KeySampleSize, "key-sample", config.KeySampleSize, "records sampled from the table for query keys")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print the full statistics of every query type")
+}
+
+// timeTravelConfigFlags registers a flag for every TimeTravelConfig field, defaulting to the current values
+func timeTravelConfigFlags(fs *flag.FlagSet, config *TimeTravelConfig) {
+	fs.IntVar(&config.TotalTransactions, "transactions", config.TotalTransactions, "transactions to seed the table with")
+	fs.IntVar(&config.TxnsPerBlock, "txns-per-block", config.TxnsPerBlock, "transactions per block, seeded and appended (max 200)")
+	fs.IntVar(&config.StartBlockNumber, "start-block", config.StartBlockNumber, "block number of the first seeded block")
+	fs.IntVar(&config.Snapshots, "snapshots", config.Snapshots, "points in history, one after each equal stage of the seeding")
+	fs.IntVar(&config.Queries, "queries", config.Queries, "lookups per query type and point")
+	fs.IntVar(&config.KeySampleSize, "key-sample", config.KeySampleSize, "records sampled from the table for query keys")
+	fs.DurationVar(&config.BlockTime, "block-time", config.BlockTime, "interval between blocks appended while the lookups run (0 = no writer)")
+	fs.BoolVar(&config.EnablePercentiles, "percentiles", config.EnablePercentiles, "calculate latency percentiles")
+	fs.BoolVar(&config.EnableDetailedStats, "detailed-stats", config.EnableDetailedStats, "print the full statistics of every query type and point")
+}
+
+// importConfigFlags registers a flag for every ImportConfig field, defaulting to the current values
+func importConfigFlags(fs *flag.FlagSet, config *ImportConfig) {
+	fs.StringVar(&config.Format, "format", config.Format, "file format: jsonl, csv, or auto (by extension, .csv = csv)")
+	fs.Func("map", "columns of the file for transfer fields, e.g. transactionHash=hash,from=sender (default: common names)", func(s string) error {
+		columns, err := parseColumnMap(s)
+		if err != nil {
+			return err
+		}
+		maps.Copy(config.Columns, columns)
+		return nil
+	})
+	fs.IntVar(&config.BatchSize, "batch-size", config.BatchSize, "records per INSERT; the checkpoint is saved after each")
+	fs.BoolVar(&config.Resume, "resume", config.Resume, "continue after the checkpoint of an interrupted import of the same file")
+	fs.BoolVar(&config.Drop, "drop", config.Drop, "drop the table before importing")
+	fs.IntVar(&config.MaxRejects, "max-rejects", config.MaxRejects, "abort after this many rejected records (0 = no limit)")
+}
+
+// exportConfigFlags registers a flag for every ExportConfig field, defaulting to the current values
+func exportConfigFlags(fs *flag.FlagSet, config *ExportConfig) {
+	fs.StringVar(&config.Format, "format", config.Format, "file format: jsonl, csv, or auto (by extension, .csv = csv)")
+	fs.IntVar(&config.FromBlock, "from-block", config.FromBlock, "first block to export, inclusive")
+	fs.IntVar(&config.ToBlock, "to-block", config.ToBlock, "last block to export, inclusive (-1 = no upper bound)")
+	fs.StringVar(&config.Start, "start", config.Start, "earliest timestamp to export, unix seconds or RFC 3339")
+	fs.StringVar(&config.End, "end", config.End, "latest timestamp to export, unix seconds or RFC 3339")
+	fs.Uint64Var(&config.Tx, "tx", config.Tx, "read the table as of this transaction (0 = the latest when the export starts)")
+	fs.IntVar(&config.PageSize, "page-size", config.PageSize, "records per query")
+}
+
+// Validate checks that the configuration describes a runnable test
+func (c TestConfig) Validate() error {
+	if c.TransactionCount <= 0 {
+		return fmt.Errorf("%w: -transactions must be positive", errUsage)
+	}
+	if err := nonNegative(map[string]int{
+		"batch-size":    c.BatchSize,
+		"hash-queries":  c.QueryHashCount,
+		"from-queries":  c.QueryFromCount,
+		"to-queries":    c.QueryToCount,
+		"block-queries": c.QueryBlockCount,
+		"warmup":        c.WarmupQueries,
+	}); err != nil {
+		return err
+	}
+	if c.BlockNumberMin < 0 || c.BlockNumberMin > c.BlockNumberMax {
+		return fmt.Errorf("%w: need 0 <= -block-min <= -block-max", errUsage)
+	}
+	return nil
+}
+
+// Validate checks that the configuration describes a runnable test
+func (c IndexPerformanceConfig) Validate() error {
+	if c.TotalTransactions <= 0 {
+		return fmt.Errorf("%w: -transactions must be positive", errUsage)
+	}
+	if c.TxnsPerBlock <= 0 || c.TxnsPerBlock > 200 {
+		return fmt.Errorf("%w: -txns-per-block must be between 1 and 200", errUsage)
+	}
+	if err := nonNegative(map[string]int{
+		"start-block":      c.StartBlockNumber,
+		"reads":            c.RandomReadCount,
+		"block-range-span": c.BlockRangeSpan,
+		"page-queries":     c.PageQueryCount,
+	}); err != nil {
+		return err
+	}
+	if c.TimeRangeSpan < 0 {
+		return fmt.Errorf("%w: -time-range-span must not be negative", errUsage)
+	}
+	if c.PageSize <= 0 || c.DeepPage <= 0 {
+		return fmt.Errorf("%w: -page-size and -deep-page must be positive", errUsage)
+	}
+
+	ratios := map[string]float64{
+		"hash-ratio":        c.ReadHashRatio,
+		"from-ratio":        c.ReadFromRatio,
+		"to-ratio":          c.ReadToRatio,
+		"block-ratio":       c.ReadBlockRatio,
+		"block-range-ratio": c.ReadBlockRangeRatio,
+		"time-range-ratio":  c.ReadTimeRangeRatio,
+		"addr-range-ratio":  c.ReadAddrRangeRatio,
+		"address-ratio":     c.ReadAddressRatio,
+	}
+	if err := validateRatios(ratios); err != nil {
+		return err
+	}
+	// Every read is one of these kinds
+	sum := 0.0
+	for _, ratio := range ratios {
+		sum += ratio
+	}
+	if sum < 1-1e-9 {
+		return fmt.Errorf("%w: read ratios add up to %.2f, not 1", errUsage, sum)
+	}
+	return nil
+}
+
+// Validate checks that the configuration describes a runnable scenario
+func (c AddTxnsConfig) Validate() error {
+	if c.TotalTransactions <= 0 || c.TxnsPerBlock <= 0 {
+		return fmt.Errorf("%w: -transactions and -txns-per-block must be positive", errUsage)
+	}
+	return nonNegative(map[string]int{
+		"start-block": c.StartBlockNumber,
+		"batch-size":  c.BatchSize,
+	})
+}
+
+// Validate checks that the configuration describes a runnable load test
+func (c LoadConfig) Validate() error {
+	if err := nonNegative(map[string]int{
+		"transactions": c.TotalTransactions,
+		"start-block":  c.StartBlockNumber,
+		"queries":      c.QueriesPerClient,
+		"max-conns":    c.MaxConns,
+	}); err != nil {
+		return err
+	}
+	if c.TotalTransactions > 0 && (c.TxnsPerBlock <= 0 || c.TxnsPerBlock > 200) {
+		return fmt.Errorf("%w: -txns-per-block must be between 1 and 200", errUsage)
+	}
+	if c.Clients <= 0 || c.MaxClients <= 0 {
+		return fmt.Errorf("%w: -clients and -max-clients must be positive", errUsage)
+	}
+	if c.QueriesPerClient == 0 && c.Duration <= 0 {
+		return fmt.Errorf("%w: need a positive -duration or -queries", errUsage)
+	}
+	if c.KeySampleSize <= 0 || c.PageSize <= 0 {
+		return fmt.Errorf("%w: -key-sample and -page-size must be positive", errUsage)
+	}
+	if c.Rate < 0 {
+		return fmt.Errorf("%w: -rate must not be negative", errUsage)
+	}
+	if c.Arrivals != ArrivalsConstant && c.Arrivals != ArrivalsPoisson {
+		return fmt.Errorf("%w: -arrivals must be %s or %s", errUsage, ArrivalsConstant, ArrivalsPoisson)
+	}
+	ratios := map[string]float64{
+		"hash-ratio": c.ReadHashRatio,
+		"from-ratio": c.ReadFromRatio,
+		"to-ratio":   c.ReadToRatio,
+	}
+	return validateRatios(ratios)
+}
+
+// Validate checks that the configuration describes a runnable mixed workload
+func (c MixedWorkloadConfig) Validate() error {
+	if c.InitialTransactions <= 0 {
+		return fmt.Errorf("%w: -transactions must be positive", errUsage)
+	}
+	if c.TxnsPerBlock <= 0 || c.TxnsPerBlock > 200 {
+		return fmt.Errorf("%w: -txns-per-block must be between 1 and 200", errUsage)
+	}
+	if c.BlockTime <= 0 {
+		return fmt.Errorf("%w: -block-time must be positive", errUsage)
+	}
+	if c.Blocks <= 0 || c.WindowBlocks <= 0 || c.Readers <= 0 || c.PageSize <= 0 || c.KeySampleSize <= 0 {
+		return fmt.Errorf("%w: -blocks, -window-blocks, -readers, -page-size and -key-sample must be positive", errUsage)
+	}
+	if err := nonNegative(map[string]int{
+		"start-block":      c.StartBlockNumber,
+		"block-range-span": c.BlockRangeSpan,
+	}); err != nil {
+		return err
+	}
+	if c.TimeRangeSpan < 0 {
+		return fmt.Errorf("%w: -time-range-span must not be negative", errUsage)
+	}
+
+	ratios := map[string]float64{
+		"hash-ratio":        c.ReadHashRatio,
+		"from-ratio":        c.ReadFromRatio,
+		"to-ratio":          c.ReadToRatio,
+		"block-range-ratio": c.ReadBlockRangeRatio,
+		"time-range-ratio":  c.ReadTimeRangeRatio,
+		"addr-range-ratio":  c.ReadAddrRangeRatio,
+		"address-ratio":     c.ReadAddressRatio,
+	}
+	return validateRatios(ratios)
+}
+
+// Validate checks that the configuration describes a runnable benchmark
+func (c VerifyConfig) Validate() error {
+	if err := nonNegative(map[string]int{
+		"transactions": c.TotalTransactions,
+		"start-block":  c.StartBlockNumber,
+	}); err != nil {
+		return err
+	}
+	if c.TotalTransactions > 0 && (c.TxnsPerBlock <= 0 || c.TxnsPerBlock > 200) {
+		return fmt.Errorf("%w: -txns-per-block must be between 1 and 200", errUsage)
+	}
+	if c.Queries <= 0 || c.KeySampleSize <= 0 {
+		return fmt.Errorf("%w: -queries and -key-sample must be positive", errUsage)
+	}
+	return nil
+}
+
+// Validate checks that the configuration describes a runnable historical read benchmark
+func (c TimeTravelConfig) Validate() error {
+	if err := nonNegative(map[string]int{
+		"start-block": c.StartBlockNumber,
+	}); err != nil {
+		return err
+	}
+	if c.BlockTime < 0 {
+		return fmt.Errorf("%w: -block-time must not be negative", errUsage)
+	}
+	if c.TxnsPerBlock <= 0 || c.TxnsPerBlock > 200 {
+		return fmt.Errorf("%w: -txns-per-block must be between 1 and 200", errUsage)
+	}
+	if c.Snapshots <= 0 || c.TotalTransactions < c.Snapshots*c.TxnsPerBlock {
+		return fmt.Errorf("%w: -snapshots must be positive, with at least one block of -transactions per snapshot", errUsage)
+	}
+	if c.Queries <= 0 || c.KeySampleSize <= 0 {
+		return fmt.Errorf("%w: -queries and -key-sample must be positive", errUsage)
+	}
+	return nil
+}
+
+// Validate checks that the configuration describes a runnable import
+func (c ImportConfig) Validate() error {
+	if c.Format != FormatAuto && c.Format != FormatJSONL && c.Format != FormatCSV {
+		return fmt.Errorf("%w: -format must be %s, %s or %s", errUsage, FormatAuto, FormatJSONL, FormatCSV)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("%w: -batch-size must be positive", errUsage)
+	}
+	if c.Resume && c.Drop {
+		return fmt.Errorf("%w: -resume and -drop cannot be combined", errUsage)
+	}
+	return nonNegative(map[string]int{
+		"max-rejects": c.MaxRejects,
+	})
+}
+
+// Validate checks that the configuration describes a runnable export
+func (c ExportConfig) Validate() error {
+	if c.Format != FormatAuto && c.Format != FormatJSONL && c.Format != FormatCSV {
+		return fmt.Errorf("%w: -format must be %s, %s or %s", errUsage, FormatAuto, FormatJSONL, FormatCSV)
+	}
+	if c.PageSize <= 0 {
+		return fmt.Errorf("%w: -page-size must be positive", errUsage)
+	}
+	if err := nonNegative(map[string]int{
+		"from-block": c.FromBlock,
+	}); err != nil {
+		return err
+	}
+	if c.ToBlock < -1 {
+		return fmt.Errorf("%w: -to-block must be a block number, or -1 for no upper bound", errUsage)
+	}
+	if c.ToBlock >= 0 && c.ToBlock < c.FromBlock {
+		return fmt.Errorf("%w: -to-block is before -from-block", errUsage)
+	}
+	_, err := c.exportRange()
+	return err
+}
+
+// nonNegative returns a usage error naming the first (by flag name) negative value
+func nonNegative(values map[string]int) error {
+	for _, name := range sortedKeys(values) {
+		if values[name] < 0 {
+			return fmt.Errorf("%w: -%s must not be negative", errUsage, name)
+		}
+	}
+	return nil
+}
+
+// validateRatios returns a usage error for the first (by flag name) ratio outside 0-1, or for ratios adding up to more than 1
+func validateRatios(ratios map[string]float64) error {
+	sum := 0.0
+	for _, name := range sortedKeys(ratios) {
+		if ratios[name] < 0 || ratios[name] > 1 {
+			return fmt.Errorf("%w: -%s must be between 0 and 1", errUsage, name)
+		}
+		sum += ratios[name]
+	}
+	if sum > 1+1e-9 {
+		return fmt.Errorf("%w: read ratios add up to %.2f, more than 1", errUsage, sum)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so validation errors are deterministic
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
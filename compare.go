@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+- Regression comparison between saved JSON reports (see report.go), the compare-reports command;
+  plain "compare" stays the benchmark command's alias it always was
+- The first report is the baseline; every other report is compared against it
+- Runs are lined up by name (e.g. with_indexes), or directly when both reports have a single run;
+  queries are lined up by metric name
+- A latency stat regresses when it grows by more than its threshold (percent) AND by at least
+  MinLatencyDelta, so sub-millisecond jitter on fast queries is not flagged.
+  The insert rate regresses when it drops by more than InsertThreshold percent
+*/
+
+// CompareThresholds configures when a change counts as a regression
+type CompareThresholds struct {
+	P50             float64       // Max allowed p50 increase, percent
+	P95             float64       // Max allowed p95 increase, percent
+	P99             float64       // Max allowed p99 increase, percent
+	InsertRate      float64       // Max allowed insert rate drop, percent
+	MinLatencyDelta time.Duration // Latency increases smaller than this are never regressions
+}
+
+// DefaultCompareThresholds returns the default regression thresholds
+func DefaultCompareThresholds() CompareThresholds {
+	return CompareThresholds{
+		P50:             10,
+		P95:             15,
+		P99:             25,
+		InsertRate:      10,
+		MinLatencyDelta: time.Millisecond,
+	}
+}
+
+// Delta is the change of one stat between the baseline and a candidate
+type Delta struct {
+	Run        string
+	Metric     string
+	Stat       string // p50, p95, p99 or rate
+	Baseline   float64
+	Candidate  float64
+	Change     float64 // Percent, positive when the candidate is higher
+	Regression bool
+}
+
+// RunComparison holds the deltas of one run of the candidate against the baseline
+// Missing lists metrics present in only one of the two reports
+type RunComparison struct {
+	Baseline  string
+	Candidate string
+	Deltas    []Delta
+	Missing   []string
+}
+
+// compareReports lines up the runs of candidate with those of baseline and computes the deltas
+func compareReports(baseline, candidate *Report, th CompareThresholds) []RunComparison {
+	var comparisons []RunComparison
+	single := len(baseline.Runs) == 1 && len(candidate.Runs) == 1
+
+	for _, cand := range candidate.Runs {
+		var base *ReportRun
+		for i := range baseline.Runs {
+			if baseline.Runs[i].Name == cand.Name || single {
+				base = &baseline.Runs[i]
+				break
+			}
+		}
+		if base == nil {
+			comparisons = append(comparisons, RunComparison{
+				Candidate: cand.Name,
+				Missing:   []string{fmt.Sprintf("run %q is not in the baseline", cand.Name)},
+			})
+			continue
+		}
+		comparisons = append(comparisons, compareRuns(base, &cand, th))
+	}
+	return comparisons
+}
+
+// compareRuns computes the latency and insert rate deltas of cand against base
+func compareRuns(base, cand *ReportRun, th CompareThresholds) RunComparison {
+	c := RunComparison{Baseline: base.Name, Candidate: cand.Name}
+
+	if base.Insert.Rate > 0 && cand.Insert.Rate > 0 {
+		d := newDelta(cand.Name, "insert", "rate", base.Insert.Rate, cand.Insert.Rate)
+		d.Regression = -d.Change > th.InsertRate
+		c.Deltas = append(c.Deltas, d)
+	}
+
+	baseQueries := make(map[string]QueryReport, len(base.Queries))
+	for _, q := range base.Queries {
+		baseQueries[q.Name] = q
+	}
+	seen := make(map[string]bool, len(cand.Queries))
+	for _, q := range cand.Queries {
+		seen[q.Name] = true
+		b, ok := baseQueries[q.Name]
+		if !ok {
+			c.Missing = append(c.Missing, fmt.Sprintf("%s is not in the baseline", q.Name))
+			continue
+		}
+		if b.Stats.Count == 0 || q.Stats.Count == 0 {
+			continue // not measured on one side
+		}
+		for _, stat := range []struct {
+			name      string
+			base      time.Duration
+			cand      time.Duration
+			threshold float64
+		}{
+			{"p50", b.Stats.P50, q.Stats.P50, th.P50},
+			{"p95", b.Stats.P95, q.Stats.P95, th.P95},
+			{"p99", b.Stats.P99, q.Stats.P99, th.P99},
+		} {
+			d := newDelta(cand.Name, q.Name, stat.name, float64(stat.base), float64(stat.cand))
+			d.Regression = d.Change > stat.threshold && stat.cand-stat.base >= th.MinLatencyDelta
+			c.Deltas = append(c.Deltas, d)
+		}
+	}
+	for _, q := range base.Queries {
+		if !seen[q.Name] {
+			c.Missing = append(c.Missing, fmt.Sprintf("%s is not in the candidate", q.Name))
+		}
+	}
+	return c
+}
+
+// newDelta computes the percent change from base to cand
+func newDelta(run, metric, stat string, base, cand float64) Delta {
+	d := Delta{Run: run, Metric: metric, Stat: stat, Baseline: base, Candidate: cand}
+	if base != 0 {
+		d.Change = (cand - base) / base * 100
+	}
+	return d
+}
+
+// Regressions returns the number of regressed stats
+func (c RunComparison) Regressions() int {
+	n := 0
+	for _, d := range c.Deltas {
+		if d.Regression {
+			n++
+		}
+	}
+	return n
+}
+
+// runCompare loads the reports, prints every candidate against the baseline (the first path)
+// and returns the total number of regressions
+func runCompare(paths []string, th CompareThresholds) (int, error) {
+	reports := make([]*Report, 0, len(paths))
+	for _, path := range paths {
+		report, err := readJSONReport(path)
+		if err != nil {
+			return 0, err
+		}
+		reports = append(reports, report)
+	}
+
+	baseline := reports[0]
+	fmt.Println("=== Benchmark Comparison ===")
+	fmt.Println()
+	fmt.Printf("Baseline: %s (%s, %s)\n", paths[0], baseline.Scenario, baseline.StartedAt.Format(time.RFC3339))
+	fmt.Printf("Thresholds: p50 +%.0f%%, p95 +%.0f%%, p99 +%.0f%% (and at least +%v), insert rate -%.0f%%\n",
+		th.P50, th.P95, th.P99, th.MinLatencyDelta, th.InsertRate)
+
+	total := 0
+	for i, candidate := range reports[1:] {
+		path := paths[i+1]
+		fmt.Println()
+		fmt.Println("═══════════════════════════════════════════════════════════")
+		fmt.Printf("CANDIDATE: %s (%s, %s)\n", path, candidate.Scenario, candidate.StartedAt.Format(time.RFC3339))
+		fmt.Println("═══════════════════════════════════════════════════════════")
+		if candidate.Scenario != baseline.Scenario {
+			fmt.Printf("⚠ WARNING: scenario %q differs from the baseline's %q\n", candidate.Scenario, baseline.Scenario)
+		}
+		printEnvironmentChanges(baseline, candidate)
+
+		for _, c := range compareReports(baseline, candidate, th) {
+			printRunComparison(c)
+			total += c.Regressions()
+		}
+	}
+
+	fmt.Println()
+	if total > 0 {
+		fmt.Printf("✗ %d regression(s) beyond the thresholds\n", total)
+	} else {
+		fmt.Println("✓ No regressions beyond the thresholds")
+	}
+	return total, nil
+}
+
+// printEnvironmentChanges prints the immudb and Go versions when they differ between the reports
+func printEnvironmentChanges(baseline, candidate *Report) {
+	if baseline.Environment.ClientVersion != candidate.Environment.ClientVersion {
+		fmt.Printf("  immudb client: %s -> %s\n", baseline.Environment.ClientVersion, candidate.Environment.ClientVersion)
+	}
+	if baseline.Environment.GoVersion != candidate.Environment.GoVersion {
+		fmt.Printf("  Go:            %s -> %s\n", baseline.Environment.GoVersion, candidate.Environment.GoVersion)
+	}
+	if len(baseline.Runs) > 0 && len(candidate.Runs) > 0 {
+		before, after := baseline.Runs[0].Server.Version, candidate.Runs[0].Server.Version
+		if before != after {
+			fmt.Printf("  immudb server: %s -> %s\n", before, after)
+		}
+	}
+}
+
+// printRunComparison prints one line per delta, marking regressions
+func printRunComparison(c RunComparison) {
+	fmt.Println()
+	if c.Baseline != "" && c.Baseline != c.Candidate {
+		fmt.Printf("Run: %s (baseline run: %s)\n", c.Candidate, c.Baseline)
+	} else {
+		fmt.Printf("Run: %s\n", c.Candidate)
+	}
+	if len(c.Deltas) > 0 {
+		fmt.Printf("  %-20s %-5s %14s %14s %9s\n", "Metric", "Stat", "Baseline", "Candidate", "Change")
+	}
+	for _, d := range c.Deltas {
+		base, cand := formatStat(d.Stat, d.Baseline), formatStat(d.Stat, d.Candidate)
+		mark := ""
+		if d.Regression {
+			mark = "  ✗ REGRESSION"
+		}
+		fmt.Printf("  %-20s %-5s %14s %14s %+8.1f%%%s\n", d.Metric, d.Stat, base, cand, d.Change, mark)
+	}
+	for _, m := range c.Missing {
+		fmt.Printf("  ⚠ %s\n", m)
+	}
+}
+
+// formatStat formats a latency (nanoseconds) or a rate for printing
+func formatStat(stat string, v float64) string {
+	if strings.HasPrefix(stat, "p") {
+		return time.Duration(v).Round(time.Microsecond).String()
+	}
+	return fmt.Sprintf("%.2f tx/s", v)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- history prints every revision of a transfer's row (see IMMUSQL/History.go): the tx that wrote
+  it, its commit time and the columns changed from the revision before, ending with the delete if
+  the row was deleted; the answer to "was this transfer ever altered after its insert?"
+- Only the current rows with the hash are looked up by default; -removed also finds a deleted row or
+  one whose hash was updated away, at the cost of a scan of the table's whole history
+- -o also writes the histories as JSON, for an audit trail
+*/
+
+// runHistory prints the revision history of the transfer with the hash, and writes it to path if given
+// removed also lists the rows that no longer carry the hash
+func runHistory(transactionHash, path string, removed bool) error {
+	tableOps := immusql.GetTableOps(appSettings)
+	histories, err := tableOps.HistoryOfRecord(context.Background(), transactionHash, removed)
+	if err != nil {
+		return err
+	}
+	if len(histories) == 0 {
+		if !removed {
+			return fmt.Errorf("no transfer with hash %s in table '%s' (-removed also looks for deleted rows)", transactionHash, tableOps.TableName())
+		}
+		return fmt.Errorf("no transfer with hash %s was ever in table '%s'", transactionHash, tableOps.TableName())
+	}
+
+	fmt.Println()
+	fmt.Println("=== Record History ===")
+	fmt.Println()
+	fmt.Printf("  Transfer: %s\n", transactionHash)
+	fmt.Printf("  Table:    %s\n", tableOps.TableName())
+	altered := false
+	for _, history := range histories {
+		fmt.Println()
+		printRecordHistory(history)
+		altered = altered || history.Altered()
+	}
+	fmt.Println()
+	if altered {
+		fmt.Println("⚠ The transfer was altered after its insert")
+	} else if removed {
+		fmt.Println("✓ The transfer was never altered after its insert")
+	} else {
+		fmt.Println("✓ The transfer's current row was never altered after its insert (-removed also checks for deleted rows)")
+	}
+
+	if path != "" {
+		data, err := json.MarshalIndent(histories, "", "  ")
+		if err != nil {
+			return err
+		}
+		if dir := filepath.Dir(path); dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ History written to %s\n", path)
+	}
+	return nil
+}
+
+// printRecordHistory prints the revisions of one row
+func printRecordHistory(history *immusql.RecordHistory) {
+	state := "current"
+	if history.Deleted {
+		state = "deleted"
+	}
+	fmt.Printf("  Record id %d (%s, revisions: %d)\n", history.ID, state, len(history.Revisions))
+	for _, revision := range history.Revisions {
+		action := "updated"
+		switch {
+		case revision.Revision == 1:
+			action = "inserted"
+		case revision.Deleted:
+			action = "deleted"
+		case len(revision.Changes) == 0:
+			action = "rewritten, unchanged"
+		}
+		fmt.Printf("    #%-3d tx %-8d %s  %s\n", revision.Revision, revision.TxID,
+			revision.CommittedAt.Format("2006-01-02 15:04:05 MST"), action)
+		if revision.Revision == 1 {
+			r := revision.Transfer
+			fmt.Printf("           block %d, index %d, %s -> %s\n", r.BlockNumber, r.TxBlockIndex, r.From, r.To)
+		}
+		for _, change := range revision.Changes {
+			fmt.Printf("           %s: %s -> %s\n", change.Column, change.Old, change.New)
+		}
+	}
+}
@@ -0,0 +1,620 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"DBTests/Config"
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Imports real transfer datasets (JSONL or CSV, e.g. an explorer dump) into the table through
+  InsertRecords, streaming the file so it can be any size
+- Columns are found by name: each transfer field accepts the common spellings (hash, tx_hash,
+  from_address, block_timestamp ...) and -map field=column overrides them
+- Every record is checked against the schema (VARCHAR[66] hashes, VARCHAR[42] addresses) before
+  it is sent; rejects are counted by reason and written with their line to <file>.rejects.jsonl
+- After every committed batch the position is saved to <file>.checkpoint. -resume skips what was
+  committed; if the run died between a commit and its checkpoint, the table's tail record tells
+  how far the last batch got, so no batch is inserted twice
+- Ctrl-C stops after the batch in flight and saves the checkpoint
+*/
+
+// ImportConfig holds the parameters of the import command
+type ImportConfig struct {
+	Path       string            // JSONL or CSV file to import
+	Format     string            // jsonl, csv, or auto (by extension)
+	Columns    map[string]string // transfer field -> column name in the file, overrides the defaults
+	BatchSize  int               // Records per INSERT, and per checkpoint
+	Resume     bool              // Continue after the last checkpoint
+	Drop       bool              // Drop the table before importing
+	MaxRejects int               // Abort after this many rejects (0 = no limit)
+}
+
+// Import file formats
+const (
+	FormatAuto  = "auto"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// DefaultImportConfig returns the default import configuration
+func DefaultImportConfig() ImportConfig {
+	return ImportConfig{
+		Format:    FormatAuto,
+		Columns:   map[string]string{},
+		BatchSize: immusql.DefaultBatchSize,
+	}
+}
+
+// Transfer fields of the import, in Config.Transfer order
+const (
+	fieldHash    = "transactionHash"
+	fieldFrom    = "from"
+	fieldTo      = "to"
+	fieldBlock   = "blockNumber"
+	fieldBlockID = "blockHash"
+	fieldIndex   = "txBlockIndex"
+	fieldTime    = "timestamp"
+)
+
+// importFields lists the transfer fields with the column names recognized for each, in lookup order
+var importFields = []struct {
+	name    string
+	aliases []string
+}{
+	{fieldHash, []string{"transactionHash", "transaction_hash", "hash", "tx_hash", "txHash"}},
+	{fieldFrom, []string{"from", "fromAddr", "from_address", "fromAddress"}},
+	{fieldTo, []string{"to", "toAddr", "to_address", "toAddress"}},
+	{fieldBlock, []string{"blockNumber", "block_number", "block"}},
+	{fieldBlockID, []string{"blockHash", "block_hash"}},
+	{fieldIndex, []string{"txBlockIndex", "transactionIndex", "transaction_index", "tx_index", "txIndex"}},
+	{fieldTime, []string{"timestamp", "block_timestamp", "blockTimestamp", "ts", "time"}},
+}
+
+var (
+	hashPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`) // VARCHAR[66]
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`) // VARCHAR[42]
+)
+
+// importRow is one record of the file before conversion: field name -> raw value
+type importRow struct {
+	line   int
+	values map[string]string
+	raw    string
+}
+
+// importReject is a row that failed to convert or validate, as written to the rejects file
+type importReject struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+	Raw    string `json:"raw"`
+}
+
+// importCheckpoint records how far an import got; written after every committed batch
+type importCheckpoint struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`     // last line of the last committed batch
+	LastHash string `json:"lastHash"` // hash of the last committed record
+	Inserted int    `json:"inserted"`
+	Rejected int    `json:"rejected"`
+}
+
+// parseColumnMap parses "field=column,field=column" into a field -> column map
+func parseColumnMap(s string) (map[string]string, error) {
+	columns := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("entry %q is not field=column", pair)
+		}
+		if !knownImportField(field) {
+			return nil, fmt.Errorf("%q is not a transfer field", field)
+		}
+		columns[field] = column
+	}
+	return columns, nil
+}
+
+// knownImportField reports whether name is a transfer field of the import
+func knownImportField(name string) bool {
+	for _, f := range importFields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// format returns the file format, from the extension when auto
+func (c ImportConfig) format() string {
+	if c.Format != FormatAuto {
+		return c.Format
+	}
+	switch strings.ToLower(filepath.Ext(c.Path)) {
+	case ".csv":
+		return FormatCSV
+	default:
+		return FormatJSONL
+	}
+}
+
+// resolveColumns maps every transfer field to the column of header that holds it
+// toAddr may be missing (every transfer is then a contract creation); other fields are required
+func (c ImportConfig) resolveColumns(header []string) (map[string]string, error) {
+	present := make(map[string]bool, len(header))
+	for _, h := range header {
+		present[h] = true
+	}
+	// Unlike a JSON object leaving out a null field, a header lacks the column for every row
+	if column, ok := c.Columns[fieldTo]; ok && !present[column] {
+		return nil, fmt.Errorf("column %q (mapped to %s) is not in the file", column, fieldTo)
+	}
+	return c.findColumns(func(column string) bool { return present[column] })
+}
+
+// findColumns maps every transfer field to its column among those present reports: the -map
+// column, else the first alias present; an error names the required fields without one
+// A -map column for toAddr is kept even when not present, it may just be null in this object
+func (c ImportConfig) findColumns(present func(string) bool) (map[string]string, error) {
+	columns := make(map[string]string, len(importFields))
+	var missing []string
+	for _, f := range importFields {
+		if column, ok := c.Columns[f.name]; ok {
+			if !present(column) && f.name != fieldTo {
+				return nil, fmt.Errorf("column %q (mapped to %s) is not in the file", column, f.name)
+			}
+			columns[f.name] = column
+			continue
+		}
+		for _, alias := range f.aliases {
+			if present(alias) {
+				columns[f.name] = alias
+				break
+			}
+		}
+		if _, ok := columns[f.name]; !ok && f.name != fieldTo {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no column for %s (use -map field=column)", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// readImportRows streams the rows of r in the configured format
+// A malformed line is yielded as an error row (values nil) so it can be rejected without stopping
+func (c ImportConfig) readImportRows(r io.Reader) iter.Seq2[importRow, error] {
+	if c.format() == FormatCSV {
+		return c.readCSVRows(r)
+	}
+	return c.readJSONLRows(r)
+}
+
+// readJSONLRows streams one JSON object per line
+// Columns are resolved per object, since a dump may leave out null fields (no "to" on a contract
+// creation); a required field without a column fails the import on the first object that lacks it
+func (c ImportConfig) readJSONLRows(r io.Reader) iter.Seq2[importRow, error] {
+	return func(yield func(importRow, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var object map[string]any
+			if err := json.Unmarshal([]byte(text), &object); err != nil {
+				if !yield(importRow{line: line, raw: text}, nil) {
+					return
+				}
+				continue
+			}
+			columns, err := c.findColumns(func(column string) bool {
+				_, ok := object[column]
+				return ok
+			})
+			if err != nil {
+				yield(importRow{}, fmt.Errorf("line %d: %w", line, err))
+				return
+			}
+			values := make(map[string]string, len(columns))
+			for field, column := range columns {
+				values[field] = jsonString(object[column])
+			}
+			if !yield(importRow{line: line, values: values, raw: text}, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(importRow{}, fmt.Errorf("failed to read %s: %w", c.Path, err))
+		}
+	}
+}
+
+// jsonString renders a JSON value as the string the converters expect (null -> "")
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// readCSVRows streams the records of a CSV file with a header row; line is the record number
+func (c ImportConfig) readCSVRows(r io.Reader) iter.Seq2[importRow, error] {
+	return func(yield func(importRow, error) bool) {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+		header, err := reader.Read()
+		if err != nil {
+			yield(importRow{}, fmt.Errorf("failed to read the CSV header of %s: %w", c.Path, err))
+			return
+		}
+		header = append([]string(nil), header...)
+		columns, err := c.resolveColumns(header)
+		if err != nil {
+			yield(importRow{}, err)
+			return
+		}
+		position := make(map[string]int, len(header))
+		for i, h := range header {
+			position[h] = i
+		}
+
+		line := 1
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			line++
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					if !yield(importRow{line: line, raw: err.Error()}, nil) {
+						return
+					}
+					continue
+				}
+				yield(importRow{}, fmt.Errorf("failed to read %s: %w", c.Path, err))
+				return
+			}
+			values := make(map[string]string, len(columns))
+			for field, column := range columns {
+				if i := position[column]; i < len(record) {
+					values[field] = record[i]
+				}
+			}
+			if !yield(importRow{line: line, values: values, raw: strings.Join(record, ",")}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// toTransfer converts and validates one row against the table schema
+func (row importRow) toTransfer() (Config.Transfer, error) {
+	if row.values == nil {
+		return Config.Transfer{}, errors.New("malformed line")
+	}
+	v := func(field string) string { return strings.TrimSpace(row.values[field]) }
+
+	var t Config.Transfer
+	var err error
+	t.TransactionHash = v(fieldHash)
+	if !hashPattern.MatchString(t.TransactionHash) {
+		return t, fmt.Errorf("transactionHash is not 0x + 64 hex characters (VARCHAR[66])")
+	}
+	t.From = v(fieldFrom)
+	if !addressPattern.MatchString(t.From) {
+		return t, fmt.Errorf("from is not 0x + 40 hex characters (VARCHAR[42])")
+	}
+	t.To = v(fieldTo)
+	if t.To != "" && !addressPattern.MatchString(t.To) {
+		return t, fmt.Errorf("to is not empty or 0x + 40 hex characters (VARCHAR[42])")
+	}
+	t.BlockHash = v(fieldBlockID)
+	if !hashPattern.MatchString(t.BlockHash) {
+		return t, fmt.Errorf("blockHash is not 0x + 64 hex characters (VARCHAR[66])")
+	}
+	if t.BlockNumber, err = parseImportInt(v(fieldBlock)); err != nil {
+		return t, fmt.Errorf("blockNumber: %w", err)
+	}
+	if t.TxBlockIndex, err = parseImportInt(v(fieldIndex)); err != nil {
+		return t, fmt.Errorf("txBlockIndex: %w", err)
+	}
+	if t.Timestamp, err = parseImportTime(v(fieldTime)); err != nil {
+		return t, fmt.Errorf("timestamp: %w", err)
+	}
+	return t, nil
+}
+
+// parseImportInt parses a non-negative decimal or 0x-prefixed hex integer (as RPC dumps use)
+func parseImportInt(s string) (int, error) {
+	var n int64
+	var err error
+	if rest, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		n, err = strconv.ParseInt(rest, 16, 64)
+	} else {
+		n, err = strconv.ParseInt(s, 10, 64)
+	}
+	if err != nil || n < 0 || n > 1<<31-1 {
+		return 0, fmt.Errorf("%q is not a non-negative INTEGER", s)
+	}
+	return int(n), nil
+}
+
+// parseImportTime parses unix seconds (decimal or hex) or an RFC 3339 / "2006-01-02 15:04:05" time
+func parseImportTime(s string) (int64, error) {
+	if n, err := parseImportInt(s); err == nil && n > 0 {
+		return int64(n), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05 MST", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not unix seconds or an RFC 3339 time", s)
+}
+
+// checkpointPath and rejectsPath are written next to the imported file
+func (c ImportConfig) checkpointPath() string { return c.Path + ".checkpoint" }
+func (c ImportConfig) rejectsPath() string    { return c.Path + ".rejects.jsonl" }
+
+// loadCheckpoint reads the checkpoint of a previous run of the same file
+func (c ImportConfig) loadCheckpoint() (importCheckpoint, error) {
+	var cp importCheckpoint
+	data, err := os.ReadFile(c.checkpointPath())
+	if err != nil {
+		return cp, fmt.Errorf("no checkpoint to resume from: %w", err)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("failed to parse %s: %w", c.checkpointPath(), err)
+	}
+	return cp, nil
+}
+
+// saveCheckpoint replaces the checkpoint atomically, so a crash never leaves half of one
+func (c ImportConfig) saveCheckpoint(cp importCheckpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.checkpointPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, c.checkpointPath())
+}
+
+// openRejects opens the rejects file for this run
+// A fresh import truncates it; a resumed one keeps the rejects up to the checkpoint line and drops
+// the rest, which the resumed run will reject again
+func (c ImportConfig) openRejects(checkpointLine int) (*os.File, error) {
+	var kept []byte
+	if c.Resume {
+		data, err := os.ReadFile(c.rejectsPath())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for line := range strings.Lines(string(data)) {
+			var reject importReject
+			if json.Unmarshal([]byte(line), &reject) == nil && reject.Line <= checkpointLine {
+				kept = append(kept, line...)
+			}
+		}
+	}
+	file, err := os.Create(c.rejectsPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(kept); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// runImport streams the file into the table and returns the report of the import
+func runImport(config ImportConfig) (*Report, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report := newReport("import", config)
+	tableOps := immusql.GetTableOps(appSettings).WithBatchSize(config.BatchSize)
+
+	file, err := os.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fmt.Println("=== Import Transfers ===")
+	fmt.Println()
+	fmt.Printf("  File:    %s (%s)\n", config.Path, config.format())
+	fmt.Printf("  Table:   %s\n", tableOps.TableName())
+	fmt.Printf("  Batches: %d records\n", config.BatchSize)
+	fmt.Println()
+
+	// Where to start: the checkpoint, corrected by the table's tail record
+	cp := importCheckpoint{Path: config.Path}
+	skipUntilHash := ""
+	if config.Resume {
+		if cp, err = config.loadCheckpoint(); err != nil {
+			return nil, err
+		}
+		tail, _, err := tableOps.GetTailRecord(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if tail != nil && tail.TransactionHash != cp.LastHash {
+			skipUntilHash = tail.TransactionHash // a batch committed after the last checkpoint
+		}
+		fmt.Printf("Resuming after line %d (%d inserted, %d rejected so far)\n", cp.Line, cp.Inserted, cp.Rejected)
+		if skipUntilHash != "" {
+			fmt.Printf("  The table ends past the checkpoint; skipping up to %s\n", skipUntilHash)
+		}
+	} else {
+		if config.Drop {
+			if err := tableOps.DropTable(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if err := tableOps.CreateTable(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	rejectsFile, err := config.openRejects(cp.Line)
+	if err != nil {
+		return nil, err
+	}
+	defer rejectsFile.Close()
+	rejects := json.NewEncoder(rejectsFile)
+	reasons := map[string]int{}
+
+	batch := make([]Config.Transfer, 0, config.BatchSize)
+	batchEnd := 0 // line of the last row in batch
+	var insertDuration time.Duration
+	inserted, rejected, batches := 0, 0, 0
+	start := time.Now()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		insertStart := time.Now()
+		if err := tableOps.InsertRecords(context.Background(), batch); err != nil {
+			return fmt.Errorf("batch ending at line %d: %w", batchEnd, err)
+		}
+		insertDuration += time.Since(insertStart)
+		inserted += len(batch)
+		cp.Line, cp.LastHash = batchEnd, batch[len(batch)-1].TransactionHash
+		cp.Inserted += len(batch)
+		cp.Rejected += rejected
+		rejected = 0
+		batch = batch[:0]
+		if err := config.saveCheckpoint(cp); err != nil {
+			return err
+		}
+		if batches++; batches%50 == 0 {
+			fmt.Printf("  %d records imported (line %d, %.0f records/s)\n",
+				cp.Inserted, cp.Line, float64(inserted)/time.Since(start).Seconds())
+		}
+		return nil
+	}
+
+	interrupted := false
+	for row, err := range config.readImportRows(file) {
+		if err != nil {
+			return nil, err
+		}
+		if row.line <= cp.Line {
+			continue // committed before the checkpoint
+		}
+
+		transfer, err := row.toTransfer()
+		if err != nil {
+			rejected++
+			reasons[err.Error()]++
+			if err := rejects.Encode(importReject{Line: row.line, Reason: err.Error(), Raw: row.raw}); err != nil {
+				return nil, fmt.Errorf("failed to write reject: %w", err)
+			}
+			if config.MaxRejects > 0 && cp.Rejected+rejected > config.MaxRejects {
+				return nil, fmt.Errorf("more than %d rejects, see %s", config.MaxRejects, config.rejectsPath())
+			}
+			continue
+		}
+		if skipUntilHash != "" {
+			if transfer.TransactionHash == skipUntilHash {
+				skipUntilHash = ""
+				cp.Line, cp.LastHash = row.line, transfer.TransactionHash // committed after the checkpoint
+			}
+			cp.Inserted++
+			continue
+		}
+		batch = append(batch, transfer)
+		batchEnd = row.line
+		if len(batch) == config.BatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				interrupted = true
+				break
+			}
+		}
+	}
+	if skipUntilHash != "" {
+		return nil, fmt.Errorf("the table's tail record %s is not in %s; is this the same file?", skipUntilHash, config.Path)
+	}
+	if !interrupted {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("=== Import Summary ===")
+	fmt.Println()
+	fmt.Printf("  Imported: %d records this run, %d in total\n", inserted, cp.Inserted)
+	fmt.Printf("  Rejected: %d in total (see %s)\n", cp.Rejected+rejected, config.rejectsPath())
+	reasonNames := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		reasonNames = append(reasonNames, reason)
+	}
+	sort.Slice(reasonNames, func(i, j int) bool { return reasons[reasonNames[i]] > reasons[reasonNames[j]] })
+	for _, reason := range reasonNames {
+		fmt.Printf("    %6d  %s\n", reasons[reason], reason)
+	}
+
+	insert := newInsertStats(inserted, insertDuration)
+	fmt.Printf("  Insert:   %v (%.2f records/s)\n", insertDuration, insert.Rate)
+	totalCount, err := tableOps.CountAllRecords(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	report.Runs = append(report.Runs, ReportRun{
+		Name:         "import",
+		Server:       serverInfo(tableOps),
+		Insert:       insert,
+		TotalRecords: totalCount,
+		Errors:       cp.Rejected + rejected,
+	})
+
+	if interrupted {
+		fmt.Printf("\n⚠ Interrupted after line %d; rerun with -resume to continue\n", cp.Line)
+		return report.finish(), errors.New("interrupted")
+	}
+	fmt.Println()
+	fmt.Println("✓ Import completed!")
+	return report.finish(), nil
+}
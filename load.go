@@ -0,0 +1,604 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Concurrent multi-client load: N workers run the explorer read mix at the same time
+- Clients either share the profile's connection pool (optionally capped with MaxConns) or each
+  own a connection opened with IMMUDB.OpenDB
+- Latency is recorded per worker and per query type; throughput is all queries over wall time
+- Sweep mode runs the same load at 1, 2, 4 ... MaxClients clients and reports where throughput
+  stops growing (the saturation point)
+- Query keys, addresses included, come from the table itself, so the load can run against seeded
+  or existing data, whatever seed wrote it
+- With Rate set, every level also runs open-loop: queries are scheduled at the target rate
+  (constant or Poisson arrivals) regardless of how fast earlier ones finish, and latency is
+  measured from the intended start time. Closed-loop clients wait for each query before sending
+  the next, so a slow server also slows the arrivals and queueing delay never shows up in the
+  percentiles (coordinated omission). Service time (from the actual start) is reported alongside
+*/
+
+// LoadConfig holds configuration for the concurrent load test
+type LoadConfig struct {
+	TotalTransactions   int           // Transactions to seed the table with (0 = use the existing data)
+	TxnsPerBlock        int           // Transactions per block when seeding
+	StartBlockNumber    int           // Starting block number when seeding
+	Clients             int           // Concurrent clients (ignored in sweep mode)
+	Sweep               bool          // Run at 1, 2, 4 ... MaxClients clients
+	MaxClients          int           // Largest concurrency level of the sweep
+	Duration            time.Duration // How long each concurrency level runs
+	QueriesPerClient    int           // Queries per client instead of Duration (0 = use Duration)
+	OwnConnections      bool          // Each client opens its own connection instead of sharing the pool
+	MaxConns            int           // Cap on the shared pool's open connections (0 = unlimited)
+	KeySampleSize       int           // Records sampled from the table to pick query keys from
+	ReadHashRatio       float64       // Ratio of hash queries (0.0-1.0)
+	ReadFromRatio       float64       // Ratio of FROM address first-page queries (0.0-1.0)
+	ReadToRatio         float64       // Ratio of TO address first-page queries (0.0-1.0)
+	PageSize            int           // Records per page for address queries
+	Rate                float64       // Open-loop target rate in queries/s over all clients (0 = closed loop only)
+	Arrivals            string        // Open-loop arrival process: constant or poisson
+	EnablePercentiles   bool          // Calculate latency percentiles
+	EnableDetailedStats bool          // Print per-query-type and per-worker statistics
+}
+
+// DefaultLoadConfig returns the default concurrent load configuration
+// Block number queries take whatever share the other ratios leave
+func DefaultLoadConfig() LoadConfig {
+	return LoadConfig{
+		TotalTransactions:   0, // Use the existing table
+		TxnsPerBlock:        200,
+		StartBlockNumber:    1000000,
+		Clients:             8,
+		MaxClients:          64,
+		Duration:            10 * time.Second,
+		KeySampleSize:       10000,
+		ReadHashRatio:       0.50, // explorer tx lookups dominate
+		ReadFromRatio:       0.20,
+		ReadToRatio:         0.20,
+		PageSize:            50,
+		Arrivals:            ArrivalsConstant,
+		EnablePercentiles:   true,
+		EnableDetailedStats: true,
+	}
+}
+
+// Open-loop arrival processes
+const (
+	ArrivalsConstant = "constant" // evenly spaced, 1/Rate apart
+	ArrivalsPoisson  = "poisson"  // exponentially distributed gaps with mean 1/Rate
+)
+
+// levels returns the concurrency levels to run
+func (c LoadConfig) levels() []int {
+	if !c.Sweep {
+		return []int{c.Clients}
+	}
+	var levels []int
+	for n := 1; n < c.MaxClients; n *= 2 {
+		levels = append(levels, n)
+	}
+	return append(levels, c.MaxClients)
+}
+
+// loadKeys are the values the workers pick query arguments from
+// Addresses are kept once per sampled record, so picking uniformly follows the data's distribution
+type loadKeys struct {
+	hashes []string
+	blocks []int
+	from   []string
+	to     []string // without the empty To of contract creations
+}
+
+// sampleLoadKeys reads query keys from records at random positions of the table, so clients spread
+// over the whole table instead of its oldest rows; the same seed draws the same positions
+func sampleLoadKeys(ctx context.Context, tableOps *immusql.TableOps, size int) (*loadKeys, error) {
+	records, err := tableOps.GetRandomRecords(ctx, size, newGenerator().Stream(sampleStream))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("table %s is empty; seed it with -transactions", tableOps.TableName())
+	}
+	return newLoadKeys(records), nil
+}
+
+// sampleStream keeps the PRNG stream of key sampling apart from the workers' and the arrivals'
+const sampleStream = 1 << 61
+
+// newLoadKeys collects the query keys of records
+func newLoadKeys(records []*Config.Transfer) *loadKeys {
+	keys := &loadKeys{}
+	for _, record := range records {
+		keys.hashes = append(keys.hashes, record.TransactionHash)
+		keys.blocks = append(keys.blocks, record.BlockNumber)
+		keys.from = append(keys.from, record.From)
+		if record.To != "" {
+			keys.to = append(keys.to, record.To)
+		}
+	}
+	if len(keys.to) == 0 {
+		keys.to = keys.from
+	}
+	return keys
+}
+
+// frequentAddresses returns the n addresses occurring most often in the sample, as sender or recipient
+func (k *loadKeys) frequentAddresses(n int) []string {
+	counts := make(map[string]int)
+	for _, address := range append(slices.Clip(k.from), k.to...) {
+		counts[address]++
+	}
+	addresses := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(addresses, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+	})
+	return addresses[:min(n, len(addresses))]
+}
+
+// loadWorker is one simulated client
+type loadWorker struct {
+	id        int
+	ops       *immusql.TableOps
+	rng       *rand.Rand
+	durations map[string][]time.Duration // per query type, in measurement order
+	all       []time.Duration
+	service   []time.Duration // open loop only: latency from the actual start, without queueing delay
+	errors    int
+}
+
+// pick chooses the next query by the configured ratios and returns its metric name and the call
+func (w *loadWorker) pick(config LoadConfig, keys *loadKeys) (string, func(context.Context) error) {
+	r := w.rng.Float64()
+	switch {
+	case r < config.ReadHashRatio:
+		hash := keys.hashes[w.rng.IntN(len(keys.hashes))]
+		return MetricHash, func(ctx context.Context) error {
+			_, err := w.ops.QueryRecord(ctx, hash)
+			return err
+		}
+	case r < config.ReadHashRatio+config.ReadFromRatio:
+		address := keys.from[w.rng.IntN(len(keys.from))]
+		return MetricFromPage, func(ctx context.Context) error {
+			_, err := w.ops.QueryRecordsByFromPage(ctx, address, config.PageSize, "")
+			return err
+		}
+	case r < config.ReadHashRatio+config.ReadFromRatio+config.ReadToRatio:
+		address := keys.to[w.rng.IntN(len(keys.to))]
+		return MetricToPage, func(ctx context.Context) error {
+			_, err := w.ops.QueryRecordsByToPage(ctx, address, config.PageSize, "")
+			return err
+		}
+	default:
+		block := keys.blocks[w.rng.IntN(len(keys.blocks))]
+		return MetricBlock, func(ctx context.Context) error {
+			_, err := w.ops.QueryRecordsByBlockNumber(ctx, block)
+			return err
+		}
+	}
+}
+
+// run issues queries until the deadline, or until QueriesPerClient queries are done
+func (w *loadWorker) run(ctx context.Context, config LoadConfig, keys *loadKeys, deadline time.Time) {
+	for n := 0; ; n++ {
+		if config.QueriesPerClient > 0 {
+			if n >= config.QueriesPerClient {
+				return
+			}
+		} else if !time.Now().Before(deadline) {
+			return
+		}
+
+		metric, query := w.pick(config, keys)
+		queryStart := time.Now()
+		err := query(ctx)
+		duration := time.Since(queryStart)
+		if err != nil {
+			w.errors++
+			continue
+		}
+		w.durations[metric] = append(w.durations[metric], duration)
+		w.all = append(w.all, duration)
+	}
+}
+
+// runOpen takes the next scheduled arrival until the schedule is used up, waits for its intended
+// start and measures latency from that time, so time spent queued behind slow queries counts
+func (w *loadWorker) runOpen(ctx context.Context, config LoadConfig, keys *loadKeys, start time.Time, schedule []time.Duration, next *atomic.Int64) {
+	for {
+		i := next.Add(1) - 1
+		if i >= int64(len(schedule)) {
+			return
+		}
+		intended := start.Add(schedule[i])
+		if wait := time.Until(intended); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		metric, query := w.pick(config, keys)
+		queryStart := time.Now()
+		err := query(ctx)
+		end := time.Now()
+		if err != nil {
+			w.errors++
+			continue
+		}
+		w.durations[metric] = append(w.durations[metric], end.Sub(intended))
+		w.all = append(w.all, end.Sub(intended))
+		w.service = append(w.service, end.Sub(queryStart))
+	}
+}
+
+// arrivalSchedule returns the intended start offsets of an open-loop run with clients clients:
+// Duration worth of arrivals at Rate, or clients*QueriesPerClient arrivals when that is set
+// Poisson gaps come from the run seed so repeated runs replay the same schedule
+func arrivalSchedule(config LoadConfig, clients int) []time.Duration {
+	rng := newGenerator().Stream(arrivalStream | uint64(clients))
+	limit := clients * config.QueriesPerClient
+	var schedule []time.Duration
+	at := 0.0 // seconds
+	for {
+		offset := time.Duration(at * float64(time.Second))
+		if limit > 0 && len(schedule) >= limit || limit == 0 && offset >= config.Duration {
+			return schedule
+		}
+		schedule = append(schedule, offset)
+		if config.Arrivals == ArrivalsPoisson {
+			at += rng.ExpFloat64() / config.Rate
+		} else {
+			at += 1 / config.Rate
+		}
+	}
+}
+
+// arrivalStream keeps the arrival schedules' PRNG streams apart from the workers'
+const arrivalStream = 1 << 62
+
+// loadLevel is the outcome of running the load at one concurrency level
+type loadLevel struct {
+	clients int
+	open    bool // open-loop run at rate
+	rate    float64
+	elapsed time.Duration
+	workers []*loadWorker
+}
+
+// mode names the loop of the level for printing
+func (l loadLevel) mode() string {
+	if l.open {
+		return "open"
+	}
+	return "closed"
+}
+
+// queries returns the number of successful queries of all workers
+func (l loadLevel) queries() int {
+	n := 0
+	for _, w := range l.workers {
+		n += len(w.all)
+	}
+	return n
+}
+
+// errors returns the number of failed queries of all workers
+func (l loadLevel) errors() int {
+	n := 0
+	for _, w := range l.workers {
+		n += w.errors
+	}
+	return n
+}
+
+// throughput returns successful queries per second over the wall time of the level
+func (l loadLevel) throughput() float64 {
+	if l.elapsed <= 0 {
+		return 0
+	}
+	return float64(l.queries()) / l.elapsed.Seconds()
+}
+
+// merged returns the latencies of metric across all workers (MetricAll for every query,
+// MetricService for the open-loop service times)
+func (l loadLevel) merged(metric string) []time.Duration {
+	var merged []time.Duration
+	for _, w := range l.workers {
+		switch metric {
+		case MetricAll:
+			merged = append(merged, w.all...)
+		case MetricService:
+			merged = append(merged, w.service...)
+		default:
+			merged = append(merged, w.durations[metric]...)
+		}
+	}
+	return merged
+}
+
+// runLoadLevel runs clients workers at once against tableOps and waits for all of them
+// With open set the workers share the arrival schedule at config.Rate instead of looping
+func runLoadLevel(ctx context.Context, tableOps *immusql.TableOps, config LoadConfig, keys *loadKeys, clients int, open bool) (loadLevel, error) {
+	level := loadLevel{clients: clients, open: open, workers: make([]*loadWorker, clients)}
+	var schedule []time.Duration
+	if open {
+		level.rate = config.Rate
+		schedule = arrivalSchedule(config, clients)
+	}
+
+	for i := range level.workers {
+		ops := tableOps
+		if config.OwnConnections {
+			db, err := IMMUDB.OpenDB(tableOps.Profile)
+			if err != nil {
+				closeWorkerConnections(level.workers[:i])
+				return level, fmt.Errorf("failed to open connection for client %d: %w", i+1, err)
+			}
+			ops = tableOps.WithDB(db)
+		}
+		gen := newGenerator()
+		rng := gen.Stream(uint64(clients)<<16 | uint64(i))
+		level.workers[i] = &loadWorker{
+			id:        i + 1,
+			ops:       ops,
+			rng:       rng,
+			durations: make(map[string][]time.Duration),
+		}
+	}
+	if config.OwnConnections {
+		defer closeWorkerConnections(level.workers)
+	}
+
+	var wg sync.WaitGroup
+	var next atomic.Int64
+	start := time.Now()
+	deadline := start.Add(config.Duration)
+	for _, w := range level.workers {
+		wg.Add(1)
+		go func(w *loadWorker) {
+			defer wg.Done()
+			if open {
+				w.runOpen(ctx, config, keys, start, schedule, &next)
+			} else {
+				w.run(ctx, config, keys, deadline)
+			}
+		}(w)
+	}
+	wg.Wait()
+	level.elapsed = time.Since(start)
+	return level, nil
+}
+
+// closeWorkerConnections closes the connections the workers own
+func closeWorkerConnections(workers []*loadWorker) {
+	for _, w := range workers {
+		if w != nil {
+			w.ops.DB.Close()
+		}
+	}
+}
+
+// runConcurrentLoadTest runs the read mix with concurrent clients, optionally sweeping the number of clients
+// Returns the structured report with one run per concurrency level (see report.go)
+func runConcurrentLoadTest(config LoadConfig) *Report {
+	ctx := context.Background()
+	report := newReport("load", config)
+	tableOps := immusql.GetTableOps(appSettings)
+	levels := config.levels()
+
+	fmt.Println("=== Concurrent Load Test ===")
+	fmt.Println()
+	fmt.Println("Test Configuration:")
+	fmt.Printf("  Clients:             %v\n", levels)
+	if config.QueriesPerClient > 0 {
+		fmt.Printf("  Per Level:           %d queries per client\n", config.QueriesPerClient)
+	} else {
+		fmt.Printf("  Per Level:           %v\n", config.Duration)
+	}
+	if config.OwnConnections {
+		fmt.Println("  Connections:         one per client")
+	} else if config.MaxConns > 0 {
+		fmt.Printf("  Connections:         shared pool (max %d)\n", config.MaxConns)
+	} else {
+		fmt.Println("  Connections:         shared pool")
+	}
+	if config.Rate > 0 {
+		fmt.Printf("  Open Loop:           %.1f queries/s, %s arrivals (after each closed-loop level)\n", config.Rate, config.Arrivals)
+	}
+	fmt.Printf("  Query Distribution:  %.0f%% hash, %.0f%% FROM page, %.0f%% TO page, %.0f%% block\n",
+		config.ReadHashRatio*100, config.ReadFromRatio*100, config.ReadToRatio*100,
+		(1-config.ReadHashRatio-config.ReadFromRatio-config.ReadToRatio)*100)
+	fmt.Println()
+
+	// 1. Seed the table, or use what is there
+	var insert InsertStats
+	if config.TotalTransactions > 0 {
+		fmt.Printf("1. Seeding table '%s' with %d transactions...\n", tableOps.TableName(), config.TotalTransactions)
+		if err := tableOps.DropTable(ctx); err != nil {
+			fatalf("Failed to drop table: %v", err)
+		}
+		if err := tableOps.CreateTable(ctx); err != nil {
+			fatalf("Failed to create table: %v", err)
+		}
+		transactions := newGenerator().BlockTransactions(config.TotalTransactions, config.TxnsPerBlock, config.StartBlockNumber)
+		insertStart := time.Now()
+		if err := tableOps.InsertRecords(ctx, transactions); err != nil {
+			fatalf("Failed to insert records: %v", err)
+		}
+		insert = newInsertStats(len(transactions), time.Since(insertStart))
+		fmt.Printf("✓ Inserted %d records in %v (%.2f tx/s)\n\n", insert.Records, insert.Duration, insert.Rate)
+	} else {
+		fmt.Printf("1. Using existing data in table '%s'\n\n", tableOps.TableName())
+	}
+
+	keys, err := sampleLoadKeys(ctx, tableOps, config.KeySampleSize)
+	if err != nil {
+		fatalf("Failed to sample query keys: %v", err)
+	}
+	totalCount, err := tableOps.CountAllRecords(ctx)
+	if err != nil {
+		fatalf("Failed to count records: %v", err)
+	}
+
+	// Cap the shared pool so N clients contend for MaxConns connections
+	if !config.OwnConnections && config.MaxConns > 0 {
+		tableOps.DB.SetMaxOpenConns(config.MaxConns)
+		defer tableOps.DB.SetMaxOpenConns(0)
+	}
+
+	// 2. Run every concurrency level
+	fmt.Println("2. Running load...")
+	server := serverInfo(tableOps)
+	var closed, open []loadLevel
+	for _, clients := range levels {
+		level, err := runLoadLevel(ctx, tableOps, config, keys, clients, false)
+		if err != nil {
+			fatalf("Load with %d clients failed: %v", clients, err)
+		}
+		closed = append(closed, level)
+		printLoadLevel(config, level)
+		report.Runs = append(report.Runs, loadRun(config, level, server, insert, totalCount))
+
+		if config.Rate <= 0 {
+			continue
+		}
+		level, err = runLoadLevel(ctx, tableOps, config, keys, clients, true)
+		if err != nil {
+			fatalf("Open-loop load with %d clients failed: %v", clients, err)
+		}
+		open = append(open, level)
+		printLoadLevel(config, level)
+		report.Runs = append(report.Runs, loadRun(config, level, server, insert, totalCount))
+	}
+
+	// 3. Summary
+	fmt.Println()
+	fmt.Println("=== Concurrent Load Summary ===")
+	fmt.Println()
+	fmt.Printf("  %7s %-6s %9s %7s %12s %12s %12s %12s\n", "Clients", "Loop", "Queries", "Errors", "Throughput", "P50", "P95", "P99")
+	for i, level := range closed {
+		printLoadSummaryRow(config, level)
+		if i < len(open) {
+			printLoadSummaryRow(config, open[i])
+		}
+	}
+	if len(closed) > 1 {
+		fmt.Println()
+		printSaturation(closed)
+	}
+	if len(open) > 0 {
+		fmt.Println()
+		printCoordinatedOmission(config, closed, open)
+	}
+	fmt.Println()
+	fmt.Println("✓ Concurrent load test completed!")
+	return report.finish()
+}
+
+// printLoadSummaryRow prints one line of the summary table
+func printLoadSummaryRow(config LoadConfig, level loadLevel) {
+	stats := calculateLatencyStats(level.merged(MetricAll), config.EnablePercentiles)
+	fmt.Printf("  %7d %-6s %9d %7d %10.1f/s %12v %12v %12v\n", level.clients, level.mode(), level.queries(), level.errors(),
+		level.throughput(), stats.P50.Round(time.Microsecond), stats.P95.Round(time.Microsecond), stats.P99.Round(time.Microsecond))
+}
+
+// printCoordinatedOmission puts the closed-loop p99 next to the open-loop p99 and service time p99
+// A large gap between open-loop latency and service time means queries queued: the target rate
+// is more than the clients can sustain, and the closed-loop p99 understates what users would see
+func printCoordinatedOmission(config LoadConfig, closed, open []loadLevel) {
+	fmt.Printf("Closed vs open loop p99 (open loop at %.1f/s, %s arrivals):\n", config.Rate, config.Arrivals)
+	for i, o := range open {
+		closedP99 := calculateLatencyStats(closed[i].merged(MetricAll), config.EnablePercentiles).P99
+		openP99 := calculateLatencyStats(o.merged(MetricAll), config.EnablePercentiles).P99
+		serviceP99 := calculateLatencyStats(o.merged(MetricService), config.EnablePercentiles).P99
+		mark := ""
+		if o.throughput() < config.Rate*0.95 {
+			mark = "  ⚠ target rate not sustained"
+		}
+		fmt.Printf("  %3d client(s): closed %v, open %v (service %v), achieved %.1f/s%s\n", o.clients,
+			closedP99.Round(time.Microsecond), openP99.Round(time.Microsecond), serviceP99.Round(time.Microsecond), o.throughput(), mark)
+	}
+}
+
+// printLoadLevel prints the aggregated, per-query-type and per-worker stats of one level
+func printLoadLevel(config LoadConfig, level loadLevel) {
+	fmt.Println()
+	fmt.Printf("  %d client(s), %s loop: %d queries, %d errors in %v (%.1f queries/s)\n",
+		level.clients, level.mode(), level.queries(), level.errors(), level.elapsed.Round(time.Millisecond), level.throughput())
+	printLatencyStats("    All Queries", calculateLatencyStats(level.merged(MetricAll), config.EnablePercentiles))
+	if level.open {
+		printLatencyStats("    Service Time", calculateLatencyStats(level.merged(MetricService), config.EnablePercentiles))
+	}
+	if !config.EnableDetailedStats {
+		return
+	}
+	for _, metric := range []string{MetricHash, MetricFromPage, MetricToPage, MetricBlock} {
+		if durations := level.merged(metric); len(durations) > 0 {
+			printLatencyStats("    "+metric, calculateLatencyStats(durations, config.EnablePercentiles))
+		}
+	}
+	for _, w := range level.workers {
+		stats := calculateLatencyStats(w.all, config.EnablePercentiles)
+		fmt.Printf("    Client %-3d %6d queries, %d errors, Mean=%v, P95=%v\n",
+			w.id, len(w.all), w.errors, stats.Mean.Round(time.Microsecond), stats.P95.Round(time.Microsecond))
+	}
+}
+
+// printSaturation reports the first level after which adding clients gains less than 10% throughput
+func printSaturation(results []loadLevel) {
+	for i := 1; i < len(results); i++ {
+		prev, cur := results[i-1], results[i]
+		if prev.throughput() > 0 && cur.throughput() < prev.throughput()*1.10 {
+			fmt.Printf("Saturation: throughput stops scaling at about %d clients (%.1f/s; %d clients reach %.1f/s)\n",
+				prev.clients, prev.throughput(), cur.clients, cur.throughput())
+			return
+		}
+	}
+	last := results[len(results)-1]
+	fmt.Printf("No saturation up to %d clients (%.1f/s)\n", last.clients, last.throughput())
+}
+
+// loadRun turns one concurrency level into a report run
+func loadRun(config LoadConfig, level loadLevel, server ServerInfo, insert InsertStats, totalCount int) ReportRun {
+	run := ReportRun{
+		Name:         fmt.Sprintf("clients_%d", level.clients),
+		Server:       server,
+		Insert:       insert,
+		TotalRecords: totalCount,
+		Clients:      level.clients,
+		Throughput:   level.throughput(),
+		Errors:       level.errors(),
+	}
+	metrics := []string{MetricAll, MetricHash, MetricFromPage, MetricToPage, MetricBlock}
+	if level.open {
+		run.Name += "_open"
+		run.TargetRate = level.rate
+		run.Arrivals = config.Arrivals
+		metrics = append(metrics, MetricService)
+	}
+	for _, metric := range metrics {
+		run.Queries = append(run.Queries,
+			newQueryReport(metric, level.merged(metric), config.EnablePercentiles, config.EnableDetailedStats))
+	}
+	for _, w := range level.workers {
+		run.Workers = append(run.Workers, WorkerReport{
+			ID:      w.id,
+			Queries: len(w.all),
+			Errors:  w.errors,
+			Stats:   calculateLatencyStats(w.all, config.EnablePercentiles),
+		})
+	}
+	return run
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"DBTests/Config"
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Mixed read/write workload: a writer appends one block every BlockTime (like the node does)
+  while readers run the index test's read mix against the same table
+- The run is cut into windows of WindowBlocks blocks; every read is attributed to the window it
+  started in, so each window reports read percentiles at its table size and write pressure
+- An idle window (readers only, no writes) runs first as the baseline at the seeded size
+- A block that takes longer than BlockTime to insert delays the next one; the writer never
+  catches up by bursting, it reports the block as late instead
+- Readers also query blocks and hashes appended during the run, not just the seeded data; their
+  addresses come from records sampled from the table, as in the load test
+- The generator belongs to the writer; each reader has its own PRNG stream, taken before it starts
+*/
+
+// MixedWorkloadConfig holds configuration for the mixed read/write test
+type MixedWorkloadConfig struct {
+	InitialTransactions int           // Transactions seeded before the run starts
+	TxnsPerBlock        int           // Transactions per block, seeded and appended (max 200)
+	StartBlockNumber    int           // Starting block number
+	BlockTime           time.Duration // Interval between appended blocks
+	Blocks              int           // Blocks the writer appends during the run
+	WindowBlocks        int           // Blocks per reporting window
+	Readers             int           // Concurrent reader clients
+	ReadHashRatio       float64       // Ratio of hash queries (0.0-1.0)
+	ReadFromRatio       float64       // Ratio of FROM address queries (0.0-1.0)
+	ReadToRatio         float64       // Ratio of TO address queries (0.0-1.0)
+	ReadBlockRangeRatio float64       // Ratio of block range queries (0.0-1.0)
+	ReadTimeRangeRatio  float64       // Ratio of time range queries (0.0-1.0)
+	ReadAddrRangeRatio  float64       // Ratio of FROM address + block range queries (0.0-1.0)
+	ReadAddressRatio    float64       // Ratio of combined in+out address queries, first page (0.0-1.0)
+	BlockRangeSpan      int           // Number of blocks covered by a range query
+	TimeRangeSpan       int64         // Seconds covered by a time range query
+	PageSize            int           // Records per page for address queries
+	KeySampleSize       int           // Records sampled from the table to pick query addresses from
+	EnablePercentiles   bool          // Calculate latency percentiles
+	EnableDetailedStats bool          // Print per-query-type statistics of every window
+}
+
+// DefaultMixedWorkloadConfig returns the default mixed read/write configuration
+// Block number queries take whatever share the other ratios leave
+func DefaultMixedWorkloadConfig() MixedWorkloadConfig {
+	return MixedWorkloadConfig{
+		InitialTransactions: 20000,
+		TxnsPerBlock:        200,
+		StartBlockNumber:    1000000,
+		BlockTime:           2 * time.Second, // node block time
+		Blocks:              30,
+		WindowBlocks:        5,
+		Readers:             4,
+		ReadHashRatio:       0.30,
+		ReadFromRatio:       0.20,
+		ReadToRatio:         0.20,
+		ReadBlockRangeRatio: 0.05,
+		ReadTimeRangeRatio:  0.05,
+		ReadAddrRangeRatio:  0.05,
+		ReadAddressRatio:    0.05,
+		BlockRangeSpan:      10,
+		TimeRangeSpan:       300,
+		PageSize:            50,
+		KeySampleSize:       1000,
+		EnablePercentiles:   true,
+		EnableDetailedStats: false,
+	}
+}
+
+// mixedMetrics lists the read query types in the order they are printed and reported
+var mixedMetrics = []string{
+	MetricHash, MetricFrom, MetricTo, MetricBlock,
+	MetricBlockRange, MetricTimeRange, MetricFromBlockRange, MetricAddress,
+}
+
+// mixedKeys holds the hashes, blocks and timestamps readers pick from; the writer appends to it
+type mixedKeys struct {
+	mu         sync.RWMutex
+	hashes     []string
+	blocks     []int
+	timestamps []int64
+}
+
+// add makes the transactions of a new block available to the readers
+func (k *mixedKeys) add(transactions []Config.Transfer) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, tx := range transactions {
+		k.hashes = append(k.hashes, tx.TransactionHash)
+		k.timestamps = append(k.timestamps, tx.Timestamp)
+	}
+	if len(transactions) > 0 {
+		k.blocks = append(k.blocks, transactions[0].BlockNumber)
+	}
+}
+
+// pick returns a random hash, block number and timestamp
+func (k *mixedKeys) pick(rng *rand.Rand) (string, int, int64) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	i := rng.IntN(len(k.hashes))
+	return k.hashes[i], k.blocks[rng.IntN(len(k.blocks))], k.timestamps[i]
+}
+
+// mixedWindow collects the measurements of one reporting window
+type mixedWindow struct {
+	name        string
+	startSize   int // records in the table when the window opened
+	endSize     int
+	blocks      int // blocks appended in the window
+	late        int // blocks that took longer than BlockTime to insert
+	inserted    int
+	elapsed     time.Duration
+	blockInsert []time.Duration
+	reads       map[string][]time.Duration
+	errors      int
+}
+
+// writeRate returns the transactions written per second during the window
+func (w *mixedWindow) writeRate() float64 {
+	if w.elapsed <= 0 {
+		return 0
+	}
+	return float64(w.inserted) / w.elapsed.Seconds()
+}
+
+// allReads returns every read latency of the window
+func (w *mixedWindow) allReads() []time.Duration {
+	var all []time.Duration
+	for _, metric := range mixedMetrics {
+		all = append(all, w.reads[metric]...)
+	}
+	return all
+}
+
+// mixedRun is the shared state of a running mixed workload
+// Readers record into the window current points at; the writer moves it forward
+type mixedRun struct {
+	mu      sync.Mutex
+	windows []*mixedWindow
+	current atomic.Int32
+	size    atomic.Int64
+}
+
+// record adds a read latency to window (or an error when err is set)
+func (r *mixedRun) record(window int32, metric string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w := r.windows[window]
+	if err != nil {
+		w.errors++
+		return
+	}
+	w.reads[metric] = append(w.reads[metric], duration)
+}
+
+// openWindow starts a new window at the current table size and makes readers record into it
+func (r *mixedRun) openWindow(name string) *mixedWindow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w := &mixedWindow{name: name, startSize: int(r.size.Load()), reads: make(map[string][]time.Duration)}
+	r.windows = append(r.windows, w)
+	r.current.Store(int32(len(r.windows) - 1))
+	return w
+}
+
+// readQuery returns the metric and the call of the next read, chosen by the configured ratios
+func (c MixedWorkloadConfig) readQuery(tableOps *immusql.TableOps, keys *mixedKeys, rng *rand.Rand, addresses *loadKeys) (string, func(context.Context) error) {
+	hash, block, ts := keys.pick(rng)
+	from, to := addresses.from[rng.IntN(len(addresses.from))], addresses.to[rng.IntN(len(addresses.to))]
+	span := max(c.BlockRangeSpan, 1)
+	discard := func(_ any, err error) error { return err }
+
+	r := rng.Float64()
+	for _, q := range []struct {
+		metric string
+		ratio  float64
+		query  func(context.Context) error
+	}{
+		{MetricHash, c.ReadHashRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecord(ctx, hash))
+		}},
+		{MetricFrom, c.ReadFromRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecordsByFrom(ctx, from))
+		}},
+		{MetricTo, c.ReadToRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecordsByTo(ctx, to))
+		}},
+		{MetricBlockRange, c.ReadBlockRangeRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecordsByBlockRange(ctx, block, block+span-1))
+		}},
+		{MetricTimeRange, c.ReadTimeRangeRatio, func(ctx context.Context) error {
+			start := time.Unix(ts, 0)
+			return discard(tableOps.QueryRecordsByTimeRange(ctx, start, start.Add(time.Duration(c.TimeRangeSpan)*time.Second)))
+		}},
+		{MetricFromBlockRange, c.ReadAddrRangeRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecordsByFromInBlockRange(ctx, from, block, block+span-1))
+		}},
+		{MetricAddress, c.ReadAddressRatio, func(ctx context.Context) error {
+			return discard(tableOps.QueryRecordsByAddress(ctx, from, immusql.DirectionBoth, c.PageSize, ""))
+		}},
+	} {
+		if r < q.ratio {
+			return q.metric, q.query
+		}
+		r -= q.ratio
+	}
+	return MetricBlock, func(ctx context.Context) error {
+		return discard(tableOps.QueryRecordsByBlockNumber(ctx, block))
+	}
+}
+
+// runMixedWorkload seeds the table, then appends blocks at BlockTime while readers run the read mix
+// Returns the structured report with the idle window and one run per write window (see report.go)
+func runMixedWorkload(config MixedWorkloadConfig) *Report {
+	ctx := context.Background()
+	report := newReport("mixed", config)
+	tableOps := immusql.GetTableOps(appSettings)
+	windowTime := config.BlockTime * time.Duration(config.WindowBlocks)
+
+	fmt.Println("=== Mixed Read/Write Workload ===")
+	fmt.Println()
+	fmt.Println("Test Configuration:")
+	fmt.Printf("  Seeded Transactions: %d\n", config.InitialTransactions)
+	fmt.Printf("  Writer:              1 block of %d txns every %v, %d blocks\n", config.TxnsPerBlock, config.BlockTime, config.Blocks)
+	fmt.Printf("  Readers:             %d\n", config.Readers)
+	fmt.Printf("  Windows:             idle %v, then every %d blocks\n", windowTime, config.WindowBlocks)
+	fmt.Println()
+
+	// 1. Seed the table
+	fmt.Printf("1. Seeding table '%s' with %d transactions...\n", tableOps.TableName(), config.InitialTransactions)
+	if err := tableOps.DropTable(ctx); err != nil {
+		fatalf("Failed to drop table: %v", err)
+	}
+	if err := tableOps.CreateTable(ctx); err != nil {
+		fatalf("Failed to create table: %v", err)
+	}
+	gen := newGenerator()
+	seed := gen.BlockTransactions(config.InitialTransactions, config.TxnsPerBlock, config.StartBlockNumber)
+	insertStart := time.Now()
+	if err := tableOps.InsertRecords(ctx, seed); err != nil {
+		fatalf("Failed to insert records: %v", err)
+	}
+	seedInsert := newInsertStats(len(seed), time.Since(insertStart))
+	fmt.Printf("✓ Inserted %d records in %v (%.2f tx/s)\n\n", seedInsert.Records, seedInsert.Duration, seedInsert.Rate)
+
+	keys := &mixedKeys{}
+	nextBlock := config.StartBlockNumber
+	for start := 0; start < len(seed); start += config.TxnsPerBlock {
+		keys.add(seed[start:min(start+config.TxnsPerBlock, len(seed))])
+		nextBlock++
+	}
+
+	addresses, err := sampleLoadKeys(ctx, tableOps, config.KeySampleSize)
+	if err != nil {
+		fatalf("Failed to sample query addresses: %v", err)
+	}
+
+	run := &mixedRun{}
+	run.size.Store(int64(len(seed)))
+	idle := run.openWindow("idle")
+
+	// 2. Start the readers; they run until the writer is done
+	fmt.Printf("2. Running %d readers (idle for %v, then with the writer)...\n", config.Readers, windowTime)
+	readCtx, stopReaders := context.WithCancel(ctx)
+	var readers sync.WaitGroup
+	for i := range config.Readers {
+		readers.Add(1)
+		go func(rng *rand.Rand) {
+			defer readers.Done()
+			for readCtx.Err() == nil {
+				window := run.current.Load()
+				metric, query := config.readQuery(tableOps, keys, rng, addresses)
+				queryStart := time.Now()
+				err := query(ctx)
+				run.record(window, metric, time.Since(queryStart), err)
+			}
+		}(gen.Stream(uint64(i)))
+	}
+
+	idleStart := time.Now()
+	time.Sleep(windowTime)
+	run.mu.Lock()
+	idle.elapsed = time.Since(idleStart)
+	idle.endSize = idle.startSize
+	run.mu.Unlock()
+	run.printWindow(config, idle)
+
+	// 3. Append blocks at BlockTime; a slow insert delays the next block instead of bursting
+	var window *mixedWindow
+	var windowStart time.Time
+	ticker := time.NewTicker(config.BlockTime)
+	for b := 0; b < config.Blocks; b++ {
+		if b%config.WindowBlocks == 0 {
+			window = run.openWindow(fmt.Sprintf("window_%d", b/config.WindowBlocks+1))
+			windowStart = time.Now()
+		}
+
+		block := gen.BlockTransactions(config.TxnsPerBlock, config.TxnsPerBlock, nextBlock)
+		blockStart := time.Now()
+		if err := tableOps.InsertRecords(ctx, block); err != nil {
+			stopReaders()
+			fatalf("Failed to insert block %d: %v", nextBlock, err)
+		}
+		blockDuration := time.Since(blockStart)
+		nextBlock++
+		keys.add(block)
+		run.size.Add(int64(len(block)))
+
+		run.mu.Lock()
+		window.blocks++
+		window.inserted += len(block)
+		window.blockInsert = append(window.blockInsert, blockDuration)
+		if blockDuration > config.BlockTime {
+			window.late++
+		}
+		run.mu.Unlock()
+
+		<-ticker.C
+		if (b+1)%config.WindowBlocks == 0 || b+1 == config.Blocks {
+			run.mu.Lock()
+			window.elapsed = time.Since(windowStart)
+			window.endSize = int(run.size.Load())
+			run.mu.Unlock()
+			run.printWindow(config, window)
+		}
+	}
+	ticker.Stop()
+	stopReaders()
+	readers.Wait()
+
+	// 4. Summary: read latency per window against table size and write pressure
+	server := serverInfo(tableOps)
+	fmt.Println()
+	fmt.Println("=== Mixed Workload Summary ===")
+	fmt.Println()
+	fmt.Printf("  %-10s %9s %11s %6s %7s %8s %12s %12s %12s\n",
+		"Window", "Records", "Write tx/s", "Late", "Reads", "Errors", "Read P50", "Read P95", "Read P99")
+	for i, w := range run.windows {
+		stats := calculateLatencyStats(w.allReads(), config.EnablePercentiles)
+		fmt.Printf("  %-10s %9d %11.1f %6d %7d %8d %12v %12v %12v\n",
+			w.name, w.endSize, w.writeRate(), w.late, stats.Count, w.errors,
+			stats.P50.Round(time.Microsecond), stats.P95.Round(time.Microsecond), stats.P99.Round(time.Microsecond))
+
+		insert := newInsertStats(w.inserted, w.elapsed)
+		if i == 0 {
+			insert = seedInsert
+		}
+		reportRun := ReportRun{
+			Name:         w.name,
+			Server:       server,
+			Insert:       insert,
+			TotalRecords: w.endSize,
+			Errors:       w.errors,
+			Timings:      []Timing{{MetricWindow, w.elapsed}},
+		}
+		reportRun.Queries = append(reportRun.Queries,
+			newQueryReport(MetricAll, w.allReads(), config.EnablePercentiles, config.EnableDetailedStats))
+		for _, metric := range mixedMetrics {
+			reportRun.Queries = append(reportRun.Queries,
+				newQueryReport(metric, w.reads[metric], config.EnablePercentiles, config.EnableDetailedStats))
+		}
+		reportRun.Queries = append(reportRun.Queries,
+			newQueryReport(MetricBlockInsert, w.blockInsert, config.EnablePercentiles, config.EnableDetailedStats))
+		report.Runs = append(report.Runs, reportRun)
+	}
+	fmt.Println()
+	fmt.Println("✓ Mixed workload completed!")
+	return report.finish()
+}
+
+// printWindow prints the write pressure and read latency of a finished window
+// Readers may still be recording into it, so it holds the lock while reading
+func (r *mixedRun) printWindow(config MixedWorkloadConfig, w *mixedWindow) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Println()
+	if w.blocks == 0 {
+		fmt.Printf("  %s: %d records, no writes, %v\n", w.name, w.endSize, w.elapsed.Round(time.Millisecond))
+	} else {
+		insertStats := calculateLatencyStats(w.blockInsert, config.EnablePercentiles)
+		fmt.Printf("  %s: %d -> %d records, %d blocks (%d late) at %.1f tx/s, block insert P95=%v\n",
+			w.name, w.startSize, w.endSize, w.blocks, w.late, w.writeRate(), insertStats.P95.Round(time.Microsecond))
+	}
+	stats := calculateLatencyStats(w.allReads(), config.EnablePercentiles)
+	fmt.Printf("    Reads: %d (%d errors), P50=%v, P95=%v, P99=%v\n", stats.Count, w.errors,
+		stats.P50.Round(time.Microsecond), stats.P95.Round(time.Microsecond), stats.P99.Round(time.Microsecond))
+	if !config.EnableDetailedStats {
+		return
+	}
+	for _, metric := range mixedMetrics {
+		if durations := w.reads[metric]; len(durations) > 0 {
+			printLatencyStats("    "+metric, calculateLatencyStats(durations, config.EnablePercentiles))
+		}
+	}
+}
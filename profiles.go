@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"DBTests/Config"
+	immusql "DBTests/IMMUSQL"
+)
+
+// profileResult pairs a connection profile with the benchmark it produced
+type profileResult struct {
+	Profile *Config.Profile
+	Result  BenchmarkResult
+}
+
+// runProfileComparison runs the same workload against every named profile and prints the results side by side
+// With no names, every profile in the settings (default first) is used
+// WARNING: the benchmark table is dropped and recreated on every target
+// Returns the structured report with one run per profile (see report.go)
+func runProfileComparison(config TestConfig, profileNames []string) *Report {
+	report := newReport("profiles", config)
+	if len(profileNames) == 0 {
+		profileNames = appSettings.ProfileNames()
+	}
+
+	fmt.Println("=== Profile Comparison ===")
+	fmt.Println()
+	fmt.Printf("Profiles: %s\n", strings.Join(profileNames, ", "))
+	fmt.Printf("Table:    %s (dropped and recreated on every profile)\n", appSettings.Table)
+	fmt.Printf("Dataset:  %d records\n", config.TransactionCount)
+	fmt.Println()
+
+	// One dataset for all targets so the numbers are comparable
+	transactions := newGenerator().TestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
+
+	results := make([]profileResult, 0, len(profileNames))
+	for _, name := range profileNames {
+		profile, err := appSettings.Resolve(name)
+		if err != nil {
+			fatalf("Failed to resolve profile: %v", err)
+		}
+
+		fmt.Println("═══════════════════════════════════════════════════════════")
+		fmt.Printf("PROFILE: %s (%s:%d/%s)\n", profile.Name, profile.Host, profile.Port, profile.Database)
+		fmt.Println("═══════════════════════════════════════════════════════════")
+
+		tableOps := immusql.GetTableOpsForProfile(appSettings, name)
+		result := runBenchmarkTest(tableOps, config, transactions, true)
+		results = append(results, profileResult{Profile: profile, Result: result})
+		report.Runs = append(report.Runs, benchmarkRun(profile.Name, serverInfo(tableOps), result, config))
+		fmt.Println()
+	}
+
+	printProfileComparison(results)
+	return report.finish()
+}
+
+// printProfileComparison prints one column per profile for every measured operation
+func printProfileComparison(results []profileResult) {
+	fmt.Println("═══════════════════════════════════════════════════════════")
+	fmt.Println("PROFILE COMPARISON RESULTS")
+	fmt.Println("═══════════════════════════════════════════════════════════")
+	fmt.Println()
+
+	fmt.Printf("%-22s", "")
+	for _, r := range results {
+		fmt.Printf(" %18s", r.Profile.Name)
+	}
+	fmt.Println()
+
+	latencyRows := []struct {
+		name  string
+		stats func(BenchmarkResult) LatencyStats
+	}{
+		{"Hash Query", func(b BenchmarkResult) LatencyStats { return b.HashStats }},
+		{"FROM Query", func(b BenchmarkResult) LatencyStats { return b.FromStats }},
+		{"TO Query", func(b BenchmarkResult) LatencyStats { return b.ToStats }},
+		{"Block Query", func(b BenchmarkResult) LatencyStats { return b.BlockStats }},
+	}
+	for _, row := range latencyRows {
+		fmt.Printf("%-22s", row.name+" Mean")
+		for _, r := range results {
+			fmt.Printf(" %18v", row.stats(r.Result).Mean.Round(time.Microsecond))
+		}
+		fmt.Println()
+		fmt.Printf("%-22s", row.name+" P95")
+		for _, r := range results {
+			fmt.Printf(" %18v", row.stats(r.Result).P95.Round(time.Microsecond))
+		}
+		fmt.Println()
+	}
+
+	durationRows := []struct {
+		name  string
+		value func(BenchmarkResult) time.Duration
+	}{
+		{"Count FROM", func(b BenchmarkResult) time.Duration { return b.CountFrom }},
+		{"Count TO", func(b BenchmarkResult) time.Duration { return b.CountTo }},
+		{"Count All", func(b BenchmarkResult) time.Duration { return b.CountAll }},
+		{"Insert Time", func(b BenchmarkResult) time.Duration { return b.InsertTime }},
+	}
+	for _, row := range durationRows {
+		fmt.Printf("%-22s", row.name)
+		for _, r := range results {
+			fmt.Printf(" %18v", row.value(r.Result).Round(time.Microsecond))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%-22s", "Insert Rate (tx/s)")
+	for _, r := range results {
+		fmt.Printf(" %18.2f", r.Result.InsertRate)
+	}
+	fmt.Println()
+	fmt.Printf("%-22s", "Total Records")
+	for _, r := range results {
+		fmt.Printf(" %18d", r.Result.TotalRecords)
+	}
+	fmt.Println()
+	fmt.Println()
+}
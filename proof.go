@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/pkg/signer"
+
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- proof writes the proof bundle of one transfer (see IMMUSQL/Proof.go) for auditors and
+  counterparties; verify-proof checks a bundle offline, without connecting to immudb
+- A bundle proves the transfer is in the ledger at its state. verify-proof prints that state:
+  compare it with one obtained from the server, or pass the server's -public-key to check the
+  state's signature (servers started with a signing key only)
+*/
+
+// runProof writes the proof bundle of the transfer with the hash to path
+func runProof(transactionHash, path string) error {
+	tableOps := immusql.GetTableOps(appSettings)
+	bundle, err := tableOps.ExportProof(context.Background(), transactionHash)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return fmt.Errorf("no transfer with hash %s in table '%s'", transactionHash, tableOps.TableName())
+	}
+	if path == "" {
+		path = transactionHash + ".proof.json"
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printProofBundle(bundle)
+	fmt.Printf("✓ Proof bundle written to %s\n", path)
+	return nil
+}
+
+// runVerifyProof checks the proof bundle in path offline, and its signature with the key in publicKeyPath if given
+func runVerifyProof(path, publicKeyPath string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var bundle immusql.ProofBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var publicKey *ecdsa.PublicKey
+	if publicKeyPath != "" {
+		if publicKey, err = signer.ParsePublicKeyFile(publicKeyPath); err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+	}
+
+	check, err := immusql.VerifyProofBundle(&bundle, publicKey)
+	if err != nil {
+		return err
+	}
+	printProofBundle(&bundle)
+	switch {
+	case check.SignatureVerified:
+		fmt.Println("  Signature: valid")
+	case check.State.Signed:
+		fmt.Println("  Signature: present, not checked (no -public-key)")
+	default:
+		fmt.Println("  Signature: none; compare the state with one obtained from the server")
+	}
+	fmt.Println()
+	fmt.Printf("✓ Transfer %s is proven in %s at tx %d\n", bundle.Record.TransactionHash, bundle.Database, check.State.TxID)
+	return nil
+}
+
+// printProofBundle prints the readable part of a bundle
+func printProofBundle(bundle *immusql.ProofBundle) {
+	fmt.Println("=== Proof Bundle ===")
+	fmt.Println()
+	fmt.Printf("  Transfer:  %s (id %d)\n", bundle.Record.TransactionHash, bundle.Record.ID)
+	fmt.Printf("  Block:     %d, index %d\n", bundle.Record.BlockNumber, bundle.Record.TxBlockIndex)
+	fmt.Printf("  Table:     %s.%s\n", bundle.Database, bundle.Table)
+	fmt.Printf("  Written:   tx %d at %s\n", bundle.Tx.ID, bundle.Tx.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  State:     tx %d, hash %s\n", bundle.State.TxID, bundle.State.TxHash)
+}
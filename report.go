@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"DBTests/Config"
+	"DBTests/IMMUDB"
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Machine-readable benchmark reports, written next to the console output
+- Every scenario returns a Report: the config it ran with, the client environment, and one
+  ReportRun per measured target (e.g. with / without indexes, or one per profile)
+- JSON holds the whole report; CSV holds one row per metric of every run, and the raw
+  samples (EnableDetailedStats) go to a separate <name>.samples.csv
+- Durations are integer nanoseconds in both formats
+*/
+
+// Metric names shared by all scenarios, so reports of different runs line up
+const (
+	MetricHash           = "hash"
+	MetricFrom           = "from"
+	MetricTo             = "to"
+	MetricBlock          = "block"
+	MetricBlockRange     = "block_range"
+	MetricTimeRange      = "time_range"
+	MetricFromBlockRange = "from_block_range"
+	MetricAddress        = "address"
+	MetricAddressPage1   = "address_page_first"
+	MetricAddressPageN   = "address_page_deep"
+	MetricCountFrom      = "count_from"
+	MetricCountTo        = "count_to"
+	MetricCountAll       = "count_all"
+	MetricTableCreate    = "table_create"
+	MetricTailRecord     = "tail_record"
+	MetricFromPage       = "from_page"
+	MetricToPage         = "to_page"
+	MetricAll            = "all"
+	MetricThroughput     = "throughput"
+	MetricService        = "service"
+	MetricTargetRate     = "target_rate"
+	MetricBlockInsert    = "block_insert"
+	MetricWindow         = "window"
+)
+
+// Report is the structured result of one simulator run
+type Report struct {
+	Scenario    string                 `json:"scenario"`
+	StartedAt   time.Time              `json:"startedAt"`
+	Duration    time.Duration          `json:"durationNs"`
+	Environment Environment            `json:"environment"`
+	Seed        uint64                 `json:"seed"` // test data seed; rerun with -seed to get the same data
+	Addresses   Config.AddressSettings `json:"addresses"`
+	Config      any                    `json:"config"`
+	Runs        []ReportRun            `json:"runs"`
+}
+
+// Environment describes the machine and client that produced a report
+type Environment struct {
+	GoVersion     string `json:"goVersion"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	Hostname      string `json:"hostname"`
+	NumCPU        int    `json:"numCPU"`
+	ClientVersion string `json:"immudbClientVersion"`
+}
+
+// ServerInfo describes the immudb target of a run
+type ServerInfo struct {
+	Profile  string `json:"profile"`
+	Backend  string `json:"backend"`
+	Address  string `json:"address"`
+	Database string `json:"database"`
+	Table    string `json:"table"`
+	Version  string `json:"version"`
+}
+
+// InsertStats describes the bulk insert of a run
+type InsertStats struct {
+	Records  int           `json:"records"`
+	Duration time.Duration `json:"durationNs"`
+	Rate     float64       `json:"ratePerSec"`
+}
+
+// QueryReport holds the latency statistics of one query type
+// Samples are the raw latencies in measurement order, only kept with EnableDetailedStats
+type QueryReport struct {
+	Name    string          `json:"name"`
+	Stats   LatencyStats    `json:"stats"`
+	Samples []time.Duration `json:"samplesNs,omitempty"`
+}
+
+// Timing is a single timed operation (count queries, table creation, ...)
+type Timing struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"durationNs"`
+}
+
+// ReportRun is one measured target of a report
+type ReportRun struct {
+	Name         string        `json:"name"`
+	Server       ServerInfo    `json:"server"`
+	Insert       InsertStats   `json:"insert"`
+	TotalRecords int           `json:"totalRecords"`
+	Queries      []QueryReport `json:"queries"`
+	Timings      []Timing      `json:"timings,omitempty"`
+
+	// Concurrent load runs only (see load.go)
+	Clients    int            `json:"clients,omitempty"`
+	Throughput float64        `json:"throughputPerSec,omitempty"`
+	Errors     int            `json:"errors,omitempty"`
+	Workers    []WorkerReport `json:"workers,omitempty"`
+
+	// Open-loop load runs only: latencies are measured from the intended start time
+	TargetRate float64 `json:"targetRatePerSec,omitempty"`
+	Arrivals   string  `json:"arrivals,omitempty"`
+}
+
+// WorkerReport holds the latencies of every query one concurrent client ran
+type WorkerReport struct {
+	ID      int          `json:"id"`
+	Queries int          `json:"queries"`
+	Errors  int          `json:"errors"`
+	Stats   LatencyStats `json:"stats"`
+}
+
+// newReport starts a report for the scenario
+func newReport(scenario string, config any) *Report {
+	return &Report{
+		Scenario:    scenario,
+		StartedAt:   time.Now().UTC(),
+		Environment: currentEnvironment(),
+		Seed:        appSettings.Seed,
+		Addresses:   appSettings.Addresses,
+		Config:      config,
+	}
+}
+
+// finish records the total duration of the run
+func (r *Report) finish() *Report {
+	r.Duration = time.Since(r.StartedAt)
+	return r
+}
+
+// currentEnvironment describes this process
+func currentEnvironment() Environment {
+	hostname, _ := os.Hostname()
+	env := Environment{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Hostname:  hostname,
+		NumCPU:    runtime.NumCPU(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/codenotary/immudb" {
+				env.ClientVersion = dep.Version
+			}
+		}
+	}
+	return env
+}
+
+// serverInfo describes the target of tableOps, asking the server for its version
+func serverInfo(tableOps *immusql.TableOps) ServerInfo {
+	profile := tableOps.Profile
+	info := ServerInfo{
+		Profile:  profile.Name,
+		Backend:  profile.Backend,
+		Address:  fmt.Sprintf("%s:%d", profile.Host, profile.Port),
+		Database: profile.Database,
+		Table:    tableOps.TableName(),
+	}
+	if info.Backend == "" {
+		info.Backend = Config.BackendRemote
+	}
+	if profile.Embedded() {
+		info.Address = "in-process"
+	}
+	version, err := IMMUDB.ServerVersion(context.Background(), tableOps.DB)
+	if err != nil {
+		fmt.Printf("  Note: could not read immudb server version: %v\n", err)
+	}
+	info.Version = version
+	return info
+}
+
+// newQueryReport summarises the latencies of one query type
+// Raw samples are only kept when detailed is set
+func newQueryReport(name string, durations []time.Duration, percentiles, detailed bool) QueryReport {
+	q := QueryReport{Name: name, Stats: calculateLatencyStats(durations, percentiles)}
+	if detailed {
+		q.Stats.Durations = append([]time.Duration(nil), durations...)
+		q.Samples = q.Stats.Durations
+	}
+	return q
+}
+
+// newInsertStats computes the insert rate of records inserted in d
+func newInsertStats(records int, d time.Duration) InsertStats {
+	stats := InsertStats{Records: records, Duration: d}
+	if d > 0 {
+		stats.Rate = float64(records) / d.Seconds()
+	}
+	return stats
+}
+
+// benchmarkRun turns a BenchmarkResult into a report run, one query entry per latency bucket
+func benchmarkRun(name string, server ServerInfo, result BenchmarkResult, config TestConfig) ReportRun {
+	query := func(name string, stats LatencyStats) QueryReport {
+		q := QueryReport{Name: name, Stats: stats}
+		if config.EnableDetailedStats {
+			q.Samples = stats.Durations
+		}
+		return q
+	}
+	return ReportRun{
+		Name:         name,
+		Server:       server,
+		Insert:       InsertStats{Records: config.TransactionCount, Duration: result.InsertTime, Rate: result.InsertRate},
+		TotalRecords: result.TotalRecords,
+		Queries: []QueryReport{
+			query(MetricHash, result.HashStats),
+			query(MetricFrom, result.FromStats),
+			query(MetricTo, result.ToStats),
+			query(MetricBlock, result.BlockStats),
+		},
+		Timings: []Timing{
+			{MetricCountFrom, result.CountFrom},
+			{MetricCountTo, result.CountTo},
+			{MetricCountAll, result.CountAll},
+		},
+	}
+}
+
+// reportOutput holds the -json and -csv paths of a command
+type reportOutput struct {
+	jsonPath string
+	csvPath  string
+}
+
+// reportFlags registers -json and -csv on fs
+func reportFlags(fs *flag.FlagSet) *reportOutput {
+	out := &reportOutput{}
+	fs.StringVar(&out.jsonPath, "json", "", "write the report as JSON to this path")
+	fs.StringVar(&out.csvPath, "csv", "", "write the report as CSV to this path (raw samples go to <name>.samples.csv)")
+	return out
+}
+
+// write saves the report in every requested format
+func (o *reportOutput) write(report *Report) error {
+	if o.jsonPath != "" {
+		if err := writeJSONReport(o.jsonPath, report); err != nil {
+			return err
+		}
+		fmt.Printf("Report written to %s\n", o.jsonPath)
+	}
+	if o.csvPath != "" {
+		samplesPath, err := writeCSVReport(o.csvPath, report)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Report written to %s\n", o.csvPath)
+		if samplesPath != "" {
+			fmt.Printf("Raw samples written to %s\n", samplesPath)
+		}
+	}
+	return nil
+}
+
+// writeJSONReport writes report to path as indented JSON
+func writeJSONReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// readJSONReport loads a report written by writeJSONReport
+func readJSONReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// csvHeader is the header of the metrics CSV
+var csvHeader = []string{
+	"scenario", "started_at", "run", "profile", "table", "server_version", "metric", "count",
+	"min_ns", "mean_ns", "p50_ns", "p95_ns", "p99_ns", "p999_ns", "max_ns", "total_ns", "rate_per_s",
+}
+
+// csvRows flattens the report into one row per query type, insert and timing of every run
+func csvRows(report *Report) [][]string {
+	ns := func(d time.Duration) string { return strconv.FormatInt(int64(d), 10) }
+	rows := [][]string{csvHeader}
+	for _, run := range report.Runs {
+		prefix := []string{
+			report.Scenario, report.StartedAt.Format(time.RFC3339),
+			run.Name, run.Server.Profile, run.Server.Table, run.Server.Version,
+		}
+		row := func(cells ...string) {
+			rows = append(rows, append(append([]string{}, prefix...), cells...))
+		}
+		row("insert", strconv.Itoa(run.Insert.Records), "", "", "", "", "", "", "",
+			ns(run.Insert.Duration), strconv.FormatFloat(run.Insert.Rate, 'f', 2, 64))
+		for _, q := range run.Queries {
+			s := q.Stats
+			row(q.Name, strconv.Itoa(s.Count), ns(s.Min), ns(s.Mean), ns(s.P50), ns(s.P95), ns(s.P99), ns(s.P999), ns(s.Max), ns(s.Total), "")
+		}
+		for _, t := range run.Timings {
+			row(t.Name, "1", "", "", "", "", "", "", "", ns(t.Duration), "")
+		}
+		if run.Throughput > 0 {
+			row(MetricThroughput, strconv.Itoa(run.Clients), "", "", "", "", "", "", "", "",
+				strconv.FormatFloat(run.Throughput, 'f', 2, 64))
+		}
+		if run.TargetRate > 0 {
+			row(MetricTargetRate, "", "", "", "", "", "", "", "", "",
+				strconv.FormatFloat(run.TargetRate, 'f', 2, 64))
+		}
+		for _, w := range run.Workers {
+			s := w.Stats
+			row(fmt.Sprintf("worker_%d", w.ID), strconv.Itoa(s.Count), ns(s.Min), ns(s.Mean), ns(s.P50), ns(s.P95), ns(s.P99), ns(s.P999), ns(s.Max), ns(s.Total), "")
+		}
+	}
+	return rows
+}
+
+// writeCSVReport writes the metrics CSV to path and, if the report has raw samples,
+// the samples CSV next to it. Returns the samples path, empty when none was written
+func writeCSVReport(path string, report *Report) (string, error) {
+	if err := writeCSVFile(path, csvRows(report)); err != nil {
+		return "", err
+	}
+
+	samples := [][]string{{"run", "metric", "seq", "duration_ns"}}
+	for _, run := range report.Runs {
+		for _, q := range run.Queries {
+			for i, d := range q.Samples {
+				samples = append(samples, []string{run.Name, q.Name, strconv.Itoa(i), strconv.FormatInt(int64(d), 10)})
+			}
+		}
+	}
+	if len(samples) == 1 {
+		return "", nil
+	}
+	samplesPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".samples.csv"
+	return samplesPath, writeCSVFile(samplesPath, samples)
+}
+
+// writeCSVFile writes rows to path
+func writeCSVFile(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
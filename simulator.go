@@ -3,12 +3,10 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
+	"math"
 	"os"
 	"sort"
 	"strconv"
@@ -16,6 +14,8 @@ import (
 	"time"
 
 	"DBTests/Config"
+	"DBTests/Generator"
+	"DBTests/IMMUDB"
 	immusql "DBTests/IMMUSQL"
 )
 
@@ -44,6 +44,15 @@ type IndexPerformanceConfig struct {
 	ReadFromRatio       float64 // Ratio of FROM address queries (0.0-1.0)
 	ReadToRatio         float64 // Ratio of TO address queries (0.0-1.0)
 	ReadBlockRatio      float64 // Ratio of block number queries (0.0-1.0)
+	ReadBlockRangeRatio float64 // Ratio of block range queries (0.0-1.0)
+	ReadTimeRangeRatio  float64 // Ratio of time range queries (0.0-1.0)
+	ReadAddrRangeRatio  float64 // Ratio of FROM address + block range queries (0.0-1.0)
+	ReadAddressRatio    float64 // Ratio of combined in+out address queries, first page (0.0-1.0)
+	BlockRangeSpan      int     // Number of blocks covered by a range query
+	TimeRangeSpan       int64   // Seconds covered by a time range query
+	PageQueryCount      int     // Number of paginated address history queries per depth (first page, deep page)
+	PageSize            int     // Records per page for paginated queries
+	DeepPage            int     // Page number measured as the "deep" page
 	EnablePercentiles   bool    // Calculate latency percentiles
 	EnableDetailedStats bool    // Enable detailed statistics collection
 }
@@ -72,36 +81,46 @@ func DefaultIndexPerformanceConfig() IndexPerformanceConfig {
 		TxnsPerBlock:        200,     // Up to 200 txns per block (realistic)
 		StartBlockNumber:    1000000, // Start from block 1M
 		RandomReadCount:     1000,    // 1000 random reads
-		ReadHashRatio:       0.40,    // 40% hash queries (explorer tx lookup)
-		ReadFromRatio:       0.25,    // 25% FROM queries (address explorer)
-		ReadToRatio:         0.25,    // 25% TO queries (address explorer)
+		ReadHashRatio:       0.30,    // 30% hash queries (explorer tx lookup)
+		ReadFromRatio:       0.20,    // 20% FROM queries (address explorer)
+		ReadToRatio:         0.20,    // 20% TO queries (address explorer)
 		ReadBlockRatio:      0.10,    // 10% block queries (block explorer)
+		ReadBlockRangeRatio: 0.05,    // 5% block range queries (block list pages)
+		ReadTimeRangeRatio:  0.05,    // 5% time range queries (activity charts)
+		ReadAddrRangeRatio:  0.05,    // 5% address + block range queries (address history window)
+		ReadAddressRatio:    0.05,    // 5% combined address queries (explorer address page)
+		BlockRangeSpan:      10,      // 10 blocks per range query
+		TimeRangeSpan:       300,     // 5 minutes per time range query
+		PageQueryCount:      100,     // 100 first-page and 100 deep-page queries
+		PageSize:            50,      // 50 records per page (explorer default)
+		DeepPage:            200,     // page 200 = rows 9,950-10,000 of an address history
 		EnablePercentiles:   true,
 		EnableDetailedStats: true,
 	}
 }
 
 // LatencyStats holds latency statistics
+// Durations are in nanoseconds when encoded as JSON (see report.go)
 type LatencyStats struct {
-	Count     int
-	Min       time.Duration
-	Max       time.Duration
-	Mean      time.Duration
-	P50       time.Duration // Median
-	P95       time.Duration
-	P99       time.Duration
-	P999      time.Duration
-	Total     time.Duration
-	Durations []time.Duration // Only populated if EnableDetailedStats
+	Count     int             `json:"count"`
+	Min       time.Duration   `json:"minNs"`
+	Max       time.Duration   `json:"maxNs"`
+	Mean      time.Duration   `json:"meanNs"`
+	P50       time.Duration   `json:"p50Ns"` // Median
+	P95       time.Duration   `json:"p95Ns"`
+	P99       time.Duration   `json:"p99Ns"`
+	P999      time.Duration   `json:"p999Ns"`
+	Total     time.Duration   `json:"totalNs"`
+	Durations []time.Duration `json:"-"` // Raw samples in measurement order, only set for reports with EnableDetailedStats
 }
 
 func runCompareOrderByTest() {
     ctx := context.Background()
-    tableOps := immusql.GetTableOps()
+    tableOps := immusql.GetTableOps(appSettings)
 
     fmt.Println("\n=== Running CompareOrderByIndexTest ===")
-    if err := tableOps.CompareOrderByIndexTest(ctx, Config.ImmuDBTable); err != nil {
-        log.Fatalf("CompareOrderByIndexTest failed: %v", err)
+    if err := tableOps.CompareOrderByIndexTest(ctx); err != nil {
+        fatalf("CompareOrderByIndexTest failed: %v", err)
     }
     fmt.Println("=== CompareOrderByIndexTest completed ===")
 }
@@ -152,10 +171,6 @@ func calculateLatencyStats(durations []time.Duration, enablePercentiles bool) La
 		}
 	}
 
-	if enablePercentiles {
-		stats.Durations = sorted
-	}
-
 	return stats
 }
 
@@ -171,100 +186,35 @@ func percentile(sorted []time.Duration, p float64) time.Duration {
 	return sorted[index]
 }
 
-// Real Ethereum addresses for testing (42 characters each: 0x + 40 hex)
-var testAddresses = []string{
-	"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
-	"0x8ba1f109551bD432803012645Aac136c22C929E7",
-	"0x1234567890123456789012345678901234567890",
-	"0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
-	"0xfedcba9876543210fedcba9876543210fedcba98",
+// newGenerator returns a test data generator seeded with the run's seed (-seed)
+// Every scenario starts its own, so the same seed gives each scenario the same dataset
+func newGenerator() *Generator.Generator {
+	return Generator.New(appSettings.Seed, appSettings.Addresses)
 }
 
-// generateTransactionHash generates a realistic 66-character transaction hash
-func generateTransactionHash() string {
-	bytes := make([]byte, 32) // 32 bytes = 64 hex chars
-	rand.Read(bytes)
-	return "0x" + hex.EncodeToString(bytes)
+// queryAddresses returns the address query targets of a scenario, drawn from the same distribution
+// as the generated senders and recipients; every call replays the same sequence
+func queryAddresses() *Generator.Addresses {
+	gen := newGenerator()
+	return gen.QueryAddresses(gen.Stream(0))
 }
 
-// generateRandomBlockNumber generates a random block number between start and end
-func generateRandomBlockNumber(start, end int) int {
-	n, _ := rand.Int(rand.Reader, big.NewInt(int64(end-start+1)))
-	return start + int(n.Int64())
-}
-
-// generateTestTransactions generates a specified number of test transactions
-func generateTestTransactions(count int, blockMin, blockMax int) []Config.Transfer {
-	transactions := make([]Config.Transfer, 0, count)
-	baseTime := time.Now().Unix()
-
-	for i := 0; i < count; i++ {
-		// Randomly select from and to addresses from test addresses
-		fromIdx := i % len(testAddresses)
-		toIdx := (i + 1) % len(testAddresses)
-
-		// Generate random block number
-		blockNumber := generateRandomBlockNumber(blockMin, blockMax)
-
-		// Generate realistic transaction hash
-		txHash := generateTransactionHash()
-
-		// Generate block hash (same format as transaction hash)
-		blockHash := generateTransactionHash()
-
-		transactions = append(transactions, Config.Transfer{
-			From:            testAddresses[fromIdx],
-			To:              testAddresses[toIdx],
-			BlockNumber:     blockNumber,
-			TransactionHash: txHash,
-			BlockHash:       blockHash,
-			TxBlockIndex:    i % 100,             // Transaction index within block (0-99)
-			Timestamp:       baseTime + int64(i), // Incrementing timestamps
-		})
-	}
-
-	return transactions
-}
-
-// generateBlockBasedTransactions generates transactions grouped by blocks (realistic pattern)
-// Each block has up to txnsPerBlock transactions
-func generateBlockBasedTransactions(totalTxns int, txnsPerBlock int, startBlock int) []Config.Transfer {
-	transactions := make([]Config.Transfer, 0, totalTxns)
-	baseTime := time.Now().Unix()
-
-	currentBlock := startBlock
-	txnsInCurrentBlock := 0
-	blockHash := generateTransactionHash() // Same hash for all txns in a block
-
-	for i := 0; i < totalTxns; i++ {
-		// Start new block if current block is full
-		if txnsInCurrentBlock >= txnsPerBlock {
-			currentBlock++
-			txnsInCurrentBlock = 0
-			blockHash = generateTransactionHash() // New block hash
-		}
-
-		// Randomly select from and to addresses
-		fromIdx := i % len(testAddresses)
-		toIdx := (i + 1) % len(testAddresses)
-
-		// Generate unique transaction hash
-		txHash := generateTransactionHash()
-
-		transactions = append(transactions, Config.Transfer{
-			From:            testAddresses[fromIdx],
-			To:              testAddresses[toIdx],
-			BlockNumber:     currentBlock,
-			TransactionHash: txHash,
-			BlockHash:       blockHash,
-			TxBlockIndex:    txnsInCurrentBlock,
-			Timestamp:       baseTime + int64(i),
-		})
-
-		txnsInCurrentBlock++
-	}
-
-	return transactions
+// describeAddresses summarizes the address settings of the generated data
+func describeAddresses() string {
+	a := appSettings.Addresses
+	var accounts string
+	switch a.Distribution {
+	case Config.DistributionCycle:
+		return fmt.Sprintf("the %d fixed test addresses in turn", len(Generator.TestAddresses))
+	case Config.DistributionZipf:
+		accounts = fmt.Sprintf("%d accounts, zipf s=%.2f", a.Count, a.ZipfS)
+	case Config.DistributionHotSet:
+		accounts = fmt.Sprintf("%d accounts, %.0f%% on a hot set of %d", a.Count, a.HotSetShare*100, a.HotSetSize)
+	default:
+		accounts = fmt.Sprintf("%d accounts, uniform", a.Count)
+	}
+	return fmt.Sprintf("%s; %.0f%% of transfers to %d contracts, %.0f%% contract creations",
+		accounts, a.ContractCallRatio*100, a.Contracts, a.CreationRatio*100)
 }
 
 // printLatencyStats prints formatted latency statistics
@@ -293,12 +243,15 @@ func printLatencyStats(name string, stats LatencyStats) {
 }
 
 // runPerformanceTest runs comprehensive performance tests with configurable parameters
-func runPerformanceTest(config TestConfig) {
+// Returns the structured report of the run (see report.go)
+func runPerformanceTest(config TestConfig) *Report {
 	ctx := context.Background()
 	overallStart := time.Now()
+	report := newReport("test", config)
 
 	// Initialize TableOps
-	tableOps := immusql.GetTableOps()
+	tableOps := immusql.GetTableOps(appSettings).WithBatchSize(config.BatchSize)
+	addresses := queryAddresses()
 	fmt.Println("=== ImmutableDB Performance Test Simulator ===")
 	fmt.Println()
 	fmt.Println("Test Configuration:")
@@ -329,17 +282,17 @@ func runPerformanceTest(config TestConfig) {
 		fmt.Println()
 	}
 
-	err := tableOps.CreateTable(ctx, Config.ImmuDBTable)
+	err := tableOps.CreateTable(ctx)
 	if err != nil {
-		log.Fatalf("Failed to create table: %v", err)
+		fatalf("Failed to create table: %v", err)
 	}
 	tableCreateDuration := time.Since(tableStart)
-	fmt.Printf("✓ Table '%s' created successfully in %v\n\n", Config.ImmuDBTable, tableCreateDuration)
+	fmt.Printf("✓ Table '%s' created successfully in %v\n\n", appSettings.Table, tableCreateDuration)
 
 	// 1.1. Test index performance if table has data
 	if countErr == nil && totalCount > 0 {
 		fmt.Println("1.1. Testing index performance on existing data...")
-		testErr := tableOps.TestIndexPerformance(ctx, Config.ImmuDBTable)
+		testErr := tableOps.TestIndexPerformance(ctx)
 		if testErr != nil {
 			fmt.Printf("  Note: Index test failed: %v\n", testErr)
 		}
@@ -349,18 +302,18 @@ func runPerformanceTest(config TestConfig) {
 	// 2. Generate test transactions
 	fmt.Printf("2. Generating %d test transactions...\n", config.TransactionCount)
 	generateStart := time.Now()
-	transactions := generateTestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
+	transactions := newGenerator().TestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
 	generateDuration := time.Since(generateStart)
 	generateRate := float64(config.TransactionCount) / generateDuration.Seconds()
 	fmt.Printf("✓ Generated %d transactions in %v (%.2f tx/s)\n", len(transactions), generateDuration, generateRate)
-	fmt.Printf("  Using %d test addresses\n\n", len(testAddresses))
+	fmt.Printf("  Addresses: %s\n\n", describeAddresses())
 
 	// 3. Batch insert all transactions
 	fmt.Printf("3. Inserting %d transactions...\n", config.TransactionCount)
 	insertStart := time.Now()
 	err = tableOps.InsertRecords(ctx, transactions)
 	if err != nil {
-		log.Fatalf("Failed to insert records: %v", err)
+		fatalf("Failed to insert records: %v", err)
 	}
 	insertDuration := time.Since(insertStart)
 	insertRate := float64(config.TransactionCount) / insertDuration.Seconds()
@@ -374,7 +327,7 @@ func runPerformanceTest(config TestConfig) {
 	tailStart := time.Now()
 	tailRecord, tailID, err := tableOps.GetTailRecord(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get tail record: %v", err)
+		fatalf("Failed to get tail record: %v", err)
 	}
 	tailDuration := time.Since(tailStart)
 	if tailRecord != nil {
@@ -425,7 +378,7 @@ func runPerformanceTest(config TestConfig) {
 		hashDurations = append(hashDurations, duration)
 
 		if err != nil && err != sql.ErrNoRows {
-			log.Fatalf("Failed to query record: %v", err)
+			fatalf("Failed to query record: %v", err)
 		}
 		if i == 0 && record != nil {
 			fmt.Printf("  Sample result: %s -> %s (Block: %d)\n",
@@ -462,14 +415,14 @@ func runPerformanceTest(config TestConfig) {
 	var totalFromRecords int
 
 	for i := 0; i < config.QueryFromCount; i++ {
-		testFromAddress := testAddresses[i%len(testAddresses)]
+		testFromAddress := addresses.Account()
 		queryStart := time.Now()
 		recordsByFrom, err := tableOps.QueryRecordsByFrom(ctx, testFromAddress)
 		duration := time.Since(queryStart)
 		fromDurations = append(fromDurations, duration)
 
 		if err != nil {
-			log.Fatalf("Failed to query records by from: %v", err)
+			fatalf("Failed to query records by from: %v", err)
 		}
 		totalFromRecords += len(recordsByFrom)
 		if i == 0 && len(recordsByFrom) > 0 {
@@ -497,14 +450,14 @@ func runPerformanceTest(config TestConfig) {
 	var totalToRecords int
 
 	for i := 0; i < config.QueryToCount; i++ {
-		testToAddress := testAddresses[i%len(testAddresses)]
+		testToAddress := addresses.Account()
 		queryStart := time.Now()
 		recordsByTo, err := tableOps.QueryRecordsByTo(ctx, testToAddress)
 		duration := time.Since(queryStart)
 		toDurations = append(toDurations, duration)
 
 		if err != nil {
-			log.Fatalf("Failed to query records by to: %v", err)
+			fatalf("Failed to query records by to: %v", err)
 		}
 		totalToRecords += len(recordsByTo)
 		if i == 0 && len(recordsByTo) > 0 {
@@ -539,7 +492,7 @@ func runPerformanceTest(config TestConfig) {
 		blockDurations = append(blockDurations, duration)
 
 		if err != nil {
-			log.Fatalf("Failed to query records by block number: %v", err)
+			fatalf("Failed to query records by block number: %v", err)
 		}
 		totalBlockRecords += len(recordsByBlock)
 		if i == 0 && len(recordsByBlock) > 0 {
@@ -564,10 +517,10 @@ func runPerformanceTest(config TestConfig) {
 	// 8. Test count by FROM address
 	fmt.Println("8. Testing count by FROM address...")
 	countFromStart := time.Now()
-	testFromAddress := testAddresses[0]
+	testFromAddress := addresses.Account()
 	countFrom, err := tableOps.CountRecords(ctx, testFromAddress)
 	if err != nil {
-		log.Fatalf("Failed to count records by from: %v", err)
+		fatalf("Failed to count records by from: %v", err)
 	}
 	countFromDuration := time.Since(countFromStart)
 	fmt.Printf("✓ Total records from %s: %d (queried in %v)\n", testFromAddress, countFrom, countFromDuration)
@@ -576,10 +529,10 @@ func runPerformanceTest(config TestConfig) {
 	// 9. Test count by TO address
 	fmt.Println("9. Testing count by TO address...")
 	countToStart := time.Now()
-	testToAddress := testAddresses[1]
+	testToAddress := addresses.Account()
 	countTo, err := tableOps.CountRecordsTo(ctx, testToAddress)
 	if err != nil {
-		log.Fatalf("Failed to count records by to: %v", err)
+		fatalf("Failed to count records by to: %v", err)
 	}
 	countToDuration := time.Since(countToStart)
 	fmt.Printf("✓ Total records to %s: %d (queried in %v)\n", testToAddress, countTo, countToDuration)
@@ -590,7 +543,7 @@ func runPerformanceTest(config TestConfig) {
 	countAllStart := time.Now()
 	totalCount, err = tableOps.CountAllRecords(ctx)
 	if err != nil {
-		log.Fatalf("Failed to count all records: %v", err)
+		fatalf("Failed to count all records: %v", err)
 	}
 	countAllDuration := time.Since(countAllStart)
 	fmt.Printf("✓ Total records in table: %d (queried in %v)\n", totalCount, countAllDuration)
@@ -629,6 +582,28 @@ func runPerformanceTest(config TestConfig) {
 		float64(config.TransactionCount)/totalDuration.Seconds())
 	fmt.Println()
 	fmt.Println("✓ All performance tests completed successfully!")
+
+	detailed := config.EnableDetailedStats
+	report.Runs = []ReportRun{{
+		Name:         "indexed",
+		Server:       serverInfo(tableOps),
+		Insert:       newInsertStats(config.TransactionCount, insertDuration),
+		TotalRecords: totalCount,
+		Queries: []QueryReport{
+			newQueryReport(MetricHash, hashDurations, config.EnablePercentiles, detailed),
+			newQueryReport(MetricFrom, fromDurations, config.EnablePercentiles, detailed),
+			newQueryReport(MetricTo, toDurations, config.EnablePercentiles, detailed),
+			newQueryReport(MetricBlock, blockDurations, config.EnablePercentiles, detailed),
+		},
+		Timings: []Timing{
+			{MetricTableCreate, tableCreateDuration},
+			{MetricTailRecord, tailDuration},
+			{MetricCountFrom, countFromDuration},
+			{MetricCountTo, countToDuration},
+			{MetricCountAll, countAllDuration},
+		},
+	}}
+	return report.finish()
 }
 
 // BenchmarkResult holds query performance results for comparison
@@ -645,40 +620,43 @@ type BenchmarkResult struct {
 	TotalRecords int
 }
 
-// runBenchmarkTest runs a performance test and returns results
-func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
+// runBenchmarkTest runs a performance test against the table tableOps is bound to and returns results
+// transactions is the dataset to insert; pass nil to generate one from config
+// Only that table is dropped and recreated, so other tables on the same connection keep their data
+func runBenchmarkTest(tableOps *immusql.TableOps, config TestConfig, transactions []Config.Transfer, withIndexes bool) BenchmarkResult {
 	ctx := context.Background()
-	tableOps := immusql.GetTableOps()
+	tableOps = tableOps.WithBatchSize(config.BatchSize)
+	addresses := queryAddresses() // same targets for the indexed and the unindexed run
 
 	// Drop table to ensure clean state
-	fmt.Printf("Dropping existing table for clean benchmark...\n")
-	tableOps.DropTable(ctx, Config.ImmuDBTable)
+	fmt.Printf("Dropping existing table '%s' for clean benchmark...\n", tableOps.TableName())
+	tableOps.DropTable(ctx)
 
 	// Create table with or without indexes
 	if withIndexes {
 		fmt.Println("Creating table WITH indexes...")
-		err := tableOps.CreateTable(ctx, Config.ImmuDBTable)
+		err := tableOps.CreateTable(ctx)
 		if err != nil {
-			log.Fatalf("Failed to create table with indexes: %v", err)
+			fatalf("Failed to create table with indexes: %v", err)
 		}
 	} else {
-		fmt.Println("Index benchmark comparison requires indexes to be created. Exiting.")
-		return BenchmarkResult{}
-		// fmt.Println("Creating table WITHOUT indexes...")
-		// err := tableOps.CreateTableWithoutIndexes(ctx, Config.ImmuDBTable)
-		// if err != nil {
-		// 	log.Fatalf("Failed to create table without indexes: %v", err)
-		// }
+		fmt.Println("Creating table WITHOUT indexes...")
+		err := tableOps.CreateTableWithoutIndexes(ctx)
+		if err != nil {
+			fatalf("Failed to create table without indexes: %v", err)
+		}
 	}
 
 	// Generate transactions
-	transactions := generateTestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
+	if transactions == nil {
+		transactions = newGenerator().TestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
+	}
 
 	// Insert data
 	insertStart := time.Now()
 	err := tableOps.InsertRecords(ctx, transactions)
 	if err != nil {
-		log.Fatalf("Failed to insert records: %v", err)
+		fatalf("Failed to insert records: %v", err)
 	}
 	insertDuration := time.Since(insertStart)
 	insertRate := float64(config.TransactionCount) / insertDuration.Seconds()
@@ -694,7 +672,7 @@ func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
 
 	fromDurations := make([]time.Duration, 0, config.QueryFromCount)
 	for i := 0; i < config.QueryFromCount; i++ {
-		testFromAddress := testAddresses[i%len(testAddresses)]
+		testFromAddress := addresses.Account()
 		queryStart := time.Now()
 		_, _ = tableOps.QueryRecordsByFrom(ctx, testFromAddress)
 		fromDurations = append(fromDurations, time.Since(queryStart))
@@ -702,7 +680,7 @@ func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
 
 	toDurations := make([]time.Duration, 0, config.QueryToCount)
 	for i := 0; i < config.QueryToCount; i++ {
-		testToAddress := testAddresses[i%len(testAddresses)]
+		testToAddress := addresses.Account()
 		queryStart := time.Now()
 		_, _ = tableOps.QueryRecordsByTo(ctx, testToAddress)
 		toDurations = append(toDurations, time.Since(queryStart))
@@ -718,12 +696,12 @@ func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
 
 	// Count queries
 	countFromStart := time.Now()
-	testFromAddress := testAddresses[0]
+	testFromAddress := addresses.Account()
 	_, _ = tableOps.CountRecords(ctx, testFromAddress)
 	countFromDuration := time.Since(countFromStart)
 
 	countToStart := time.Now()
-	testToAddress := testAddresses[1]
+	testToAddress := addresses.Account()
 	_, _ = tableOps.CountRecordsTo(ctx, testToAddress)
 	countToDuration := time.Since(countToStart)
 
@@ -731,11 +709,19 @@ func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
 	totalCount, _ := tableOps.CountAllRecords(ctx)
 	countAllDuration := time.Since(countAllStart)
 
+	// Raw samples are only kept for the report when detailed stats are requested
+	stats := func(durations []time.Duration) LatencyStats {
+		s := calculateLatencyStats(durations, config.EnablePercentiles)
+		if config.EnableDetailedStats {
+			s.Durations = durations
+		}
+		return s
+	}
 	return BenchmarkResult{
-		HashStats:    calculateLatencyStats(hashDurations, config.EnablePercentiles),
-		FromStats:    calculateLatencyStats(fromDurations, config.EnablePercentiles),
-		ToStats:      calculateLatencyStats(toDurations, config.EnablePercentiles),
-		BlockStats:   calculateLatencyStats(blockDurations, config.EnablePercentiles),
+		HashStats:    stats(hashDurations),
+		FromStats:    stats(fromDurations),
+		ToStats:      stats(toDurations),
+		BlockStats:   stats(blockDurations),
 		CountFrom:    countFromDuration,
 		CountTo:      countToDuration,
 		CountAll:     countAllDuration,
@@ -745,10 +731,14 @@ func runBenchmarkTest(config TestConfig, withIndexes bool) BenchmarkResult {
 	}
 }
 
-// runIndexBenchmarkComparison runs benchmark comparison with and without indexes
-func runIndexBenchmarkComparison() {
-	// Use a smaller config for faster benchmarking
-	config := TestConfig{
+// noIndexTableName returns the table used for the WITHOUT indexes side of the benchmark comparison
+func noIndexTableName() string {
+	return appSettings.Table + "_noidx"
+}
+
+// DefaultBenchmarkConfig returns the configuration used by the index benchmark comparison
+func DefaultBenchmarkConfig() TestConfig {
+	return TestConfig{
 		TransactionCount:    500000, // Smaller dataset for faster comparison
 		QueryHashCount:      1000,
 		QueryFromCount:      1000,
@@ -760,7 +750,14 @@ func runIndexBenchmarkComparison() {
 		EnablePercentiles:   true,
 		EnableDetailedStats: true,
 	}
+}
 
+// runIndexBenchmarkComparison runs benchmark comparison with and without indexes
+// Each side uses its own table, so the indexed table keeps its data after the unindexed run
+// When interactive is false the confirmation prompt is skipped
+// Returns the structured report with one run per table (see report.go)
+func runIndexBenchmarkComparison(config TestConfig, interactive bool) *Report {
+	report := newReport("benchmark", config)
 	fmt.Println("=== Index Benchmark Comparison ===")
 	fmt.Println()
 	fmt.Println("This will run the same test twice:")
@@ -774,12 +771,13 @@ func runIndexBenchmarkComparison() {
 	fmt.Printf("  Query To Count:    %d\n", config.QueryToCount)
 	fmt.Printf("  Query Block Count: %d\n", config.QueryBlockCount)
 	fmt.Println()
-	fmt.Println("⚠ WARNING: This will drop and recreate the table!")
+	fmt.Printf("Tables: %s (with indexes), %s (without indexes)\n", appSettings.Table, noIndexTableName())
+	fmt.Println()
+	fmt.Println("⚠ WARNING: This will drop and recreate both tables!")
 	fmt.Println("Press Enter to continue or Ctrl+C to cancel...")
 
-	// Check if running in non-interactive mode (stdin is not a terminal)
 	// In non-interactive mode, skip the prompt and proceed
-	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+	if !interactive {
 		fmt.Println("(Non-interactive mode: proceeding automatically)")
 		time.Sleep(1 * time.Second) // Brief pause for visibility
 	} else {
@@ -792,7 +790,10 @@ func runIndexBenchmarkComparison() {
 	fmt.Println("TEST 1: WITH INDEXES")
 	fmt.Println("═══════════════════════════════════════════════════════════")
 	fmt.Println()
-	withIndexesResult := runBenchmarkTest(config, true)
+	// Both tables share the connection and the dataset, so the only difference is the indexes
+	tableOps := immusql.GetTableOps(appSettings)
+	transactions := newGenerator().TestTransactions(config.TransactionCount, config.BlockNumberMin, config.BlockNumberMax)
+	withIndexesResult := runBenchmarkTest(tableOps, config, transactions, true)
 
 	// Small delay between tests
 	time.Sleep(2 * time.Second)
@@ -803,7 +804,12 @@ func runIndexBenchmarkComparison() {
 	fmt.Println("TEST 2: WITHOUT INDEXES")
 	fmt.Println("═══════════════════════════════════════════════════════════")
 	fmt.Println()
-	withoutIndexesResult := runBenchmarkTest(config, false)
+	noIndexOps := tableOps.Table(noIndexTableName())
+	withoutIndexesResult := runBenchmarkTest(noIndexOps, config, transactions, false)
+	report.Runs = []ReportRun{
+		benchmarkRun("with_indexes", serverInfo(tableOps), withIndexesResult, config),
+		benchmarkRun("without_indexes", serverInfo(noIndexOps), withoutIndexesResult, config),
+	}
 
 	// Comparison
 	fmt.Println()
@@ -949,12 +955,13 @@ func runIndexBenchmarkComparison() {
 		}
 	}
 	fmt.Println()
+	return report.finish()
 }
 
 // queryTableState queries and displays the current state of the table
 func queryTableState() {
 	ctx := context.Background()
-	tableOps := immusql.GetTableOps()
+	tableOps := immusql.GetTableOps(appSettings)
 
 	fmt.Println("=== Querying Current Table State ===")
 	fmt.Println()
@@ -962,7 +969,7 @@ func queryTableState() {
 	// Get total count
 	totalCount, err := tableOps.CountAllRecords(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get total count: %v", err)
+		fatalf("Failed to get total count: %v", err)
 	}
 
 	if totalCount == 0 {
@@ -976,7 +983,7 @@ func queryTableState() {
 	// Get table statistics
 	stats, err := tableOps.GetTableStatistics(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get table statistics: %v", err)
+		fatalf("Failed to get table statistics: %v", err)
 	}
 
 	fmt.Println("Table Statistics:")
@@ -1004,12 +1011,12 @@ func queryTableState() {
 	// Get head and tail records
 	headRecord, headID, err := tableOps.GetHeadRecord(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get head record: %v", err)
+		fatalf("Failed to get head record: %v", err)
 	}
 
 	tailRecord, tailID, err := tableOps.GetTailRecord(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get tail record: %v", err)
+		fatalf("Failed to get tail record: %v", err)
 	}
 
 	fmt.Println("Record Range:")
@@ -1037,7 +1044,7 @@ func queryTableState() {
 	}
 	sampleRecords, err := tableOps.GetSampleRecords(ctx, sampleSize)
 	if err != nil {
-		log.Fatalf("Failed to get sample records: %v", err)
+		fatalf("Failed to get sample records: %v", err)
 	}
 
 	if len(sampleRecords) > 0 {
@@ -1050,9 +1057,13 @@ func queryTableState() {
 		fmt.Println()
 	}
 
-	// Count by test addresses
-	fmt.Println("Record Counts by Test Addresses:")
-	for _, addr := range testAddresses {
+	// Count by the most frequent addresses of the table, whatever seed wrote it
+	keys, err := sampleLoadKeys(ctx, tableOps, 1000)
+	if err != nil {
+		fatalf("Failed to sample addresses: %v", err)
+	}
+	fmt.Println("Record Counts by Sample Addresses:")
+	for _, addr := range keys.frequentAddresses(5) {
 		fromCount, _ := tableOps.CountRecords(ctx, addr)
 		toCount, _ := tableOps.CountRecordsTo(ctx, addr)
 		if fromCount > 0 || toCount > 0 {
@@ -1067,11 +1078,14 @@ func queryTableState() {
 }
 
 // runIndexPerformanceTest runs index performance test with realistic workload
-func runIndexPerformanceTest(config IndexPerformanceConfig) {
+// Returns the structured report of the run (see report.go)
+func runIndexPerformanceTest(config IndexPerformanceConfig) *Report {
 	ctx := context.Background()
 	overallStart := time.Now()
+	report := newReport("index", config)
 
-	tableOps := immusql.GetTableOps()
+	tableOps := immusql.GetTableOps(appSettings)
+	addresses := queryAddresses()
 
 	fmt.Println("=== Index Performance Test ===")
 	fmt.Println()
@@ -1085,14 +1099,20 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	fmt.Printf("    - FROM Queries:    %.1f%%\n", config.ReadFromRatio*100)
 	fmt.Printf("    - TO Queries:      %.1f%%\n", config.ReadToRatio*100)
 	fmt.Printf("    - Block Queries:   %.1f%%\n", config.ReadBlockRatio*100)
+	fmt.Printf("    - Block Range:     %.1f%% (%d blocks)\n", config.ReadBlockRangeRatio*100, config.BlockRangeSpan)
+	fmt.Printf("    - Time Range:      %.1f%% (%ds)\n", config.ReadTimeRangeRatio*100, config.TimeRangeSpan)
+	fmt.Printf("    - FROM + Blocks:   %.1f%% (%d blocks)\n", config.ReadAddrRangeRatio*100, config.BlockRangeSpan)
+	fmt.Printf("    - Address (both):  %.1f%% (%d per page)\n", config.ReadAddressRatio*100, config.PageSize)
+	fmt.Printf("  Paginated Queries:   %d first page + %d page %d (%d per page)\n",
+		config.PageQueryCount, config.PageQueryCount, config.DeepPage, config.PageSize)
 	fmt.Println()
 
 	// 1. Create Table
 	fmt.Println("1. Creating table with indexes...")
 	tableStart := time.Now()
-	err := tableOps.CreateTable(ctx, Config.ImmuDBTable)
+	err := tableOps.CreateTable(ctx)
 	if err != nil {
-		log.Fatalf("Failed to create table: %v", err)
+		fatalf("Failed to create table: %v", err)
 	}
 	tableCreateDuration := time.Since(tableStart)
 	fmt.Printf("✓ Table created in %v\n\n", tableCreateDuration)
@@ -1101,7 +1121,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	fmt.Printf("2. Generating %d transactions (block-based, up to %d per block)...\n",
 		config.TotalTransactions, config.TxnsPerBlock)
 	generateStart := time.Now()
-	transactions := generateBlockBasedTransactions(
+	transactions := newGenerator().BlockTransactions(
 		config.TotalTransactions,
 		config.TxnsPerBlock,
 		config.StartBlockNumber,
@@ -1131,7 +1151,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 			blockStart := time.Now()
 			err = tableOps.InsertRecords(ctx, blockTxs)
 			if err != nil {
-				log.Fatalf("Failed to insert block %d: %v", blockNum, err)
+				fatalf("Failed to insert block %d: %v", blockNum, err)
 			}
 			blockDuration := time.Since(blockStart)
 			blockInsertDurations = append(blockInsertDurations, blockDuration)
@@ -1158,13 +1178,14 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	fmt.Printf("4. Running %d random read queries (simulating explorer workload)...\n", config.RandomReadCount)
 
 	// Calculate query counts based on ratios
-	hashQueryCount := int(float64(config.RandomReadCount) * config.ReadHashRatio)
-	fromQueryCount := int(float64(config.RandomReadCount) * config.ReadFromRatio)
-	toQueryCount := int(float64(config.RandomReadCount) * config.ReadToRatio)
-	blockQueryCount := config.RandomReadCount - hashQueryCount - fromQueryCount - toQueryCount
-
-	fmt.Printf("  Query breakdown: %d hash, %d FROM, %d TO, %d block\n",
-		hashQueryCount, fromQueryCount, toQueryCount, blockQueryCount)
+	counts := splitByRatios(config.RandomReadCount, config.ReadHashRatio, config.ReadFromRatio, config.ReadToRatio,
+		config.ReadBlockRatio, config.ReadBlockRangeRatio, config.ReadTimeRangeRatio, config.ReadAddrRangeRatio, config.ReadAddressRatio)
+	hashQueryCount, fromQueryCount, toQueryCount, blockQueryCount := counts[0], counts[1], counts[2], counts[3]
+	blockRangeQueryCount, timeRangeQueryCount, addrRangeQueryCount, addressQueryCount := counts[4], counts[5], counts[6], counts[7]
+
+	fmt.Printf("  Query breakdown: %d hash, %d FROM, %d TO, %d block, %d block range, %d time range, %d FROM + blocks, %d address\n",
+		hashQueryCount, fromQueryCount, toQueryCount, blockQueryCount,
+		blockRangeQueryCount, timeRangeQueryCount, addrRangeQueryCount, addressQueryCount)
 	fmt.Println()
 
 	// Hash queries (indexed on transactionHash)
@@ -1182,7 +1203,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 			hashDurations = append(hashDurations, duration)
 
 			if err != nil && err != sql.ErrNoRows {
-				log.Fatalf("Failed to query by hash: %v", err)
+				fatalf("Failed to query by hash: %v", err)
 			}
 
 			if hashQueryCount > 50 && (i+1)%(hashQueryCount/10) == 0 {
@@ -1204,9 +1225,8 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	if fromQueryCount > 0 {
 		fmt.Printf("  4.2. FROM Address Queries (%d) - Index: fromAddr\n", fromQueryCount)
 		for i := 0; i < fromQueryCount; i++ {
-			// Random address from test addresses
-			addrIdx := i % len(testAddresses)
-			testFromAddress := testAddresses[addrIdx]
+			// Address drawn like the generated senders
+			testFromAddress := addresses.Account()
 
 			queryStart := time.Now()
 			records, err := tableOps.QueryRecordsByFrom(ctx, testFromAddress)
@@ -1214,7 +1234,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 			fromDurations = append(fromDurations, duration)
 
 			if err != nil {
-				log.Fatalf("Failed to query by FROM: %v", err)
+				fatalf("Failed to query by FROM: %v", err)
 			}
 			totalFromRecords += len(records)
 
@@ -1238,9 +1258,8 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	if toQueryCount > 0 {
 		fmt.Printf("  4.3. TO Address Queries (%d) - Index: toAddr\n", toQueryCount)
 		for i := 0; i < toQueryCount; i++ {
-			// Random address from test addresses
-			addrIdx := i % len(testAddresses)
-			testToAddress := testAddresses[addrIdx]
+			// Address drawn like the generated recipients
+			testToAddress := addresses.Account()
 
 			queryStart := time.Now()
 			records, err := tableOps.QueryRecordsByTo(ctx, testToAddress)
@@ -1248,7 +1267,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 			toDurations = append(toDurations, duration)
 
 			if err != nil {
-				log.Fatalf("Failed to query by TO: %v", err)
+				fatalf("Failed to query by TO: %v", err)
 			}
 			totalToRecords += len(records)
 
@@ -1282,7 +1301,7 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 			blockDurations = append(blockDurations, duration)
 
 			if err != nil {
-				log.Fatalf("Failed to query by block: %v", err)
+				fatalf("Failed to query by block: %v", err)
 			}
 			totalBlockRecords += len(records)
 
@@ -1300,6 +1319,50 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 		fmt.Println()
 	}
 
+	// Range queries - targets are taken from the inserted data so every range has rows
+	span := config.BlockRangeSpan
+	if span < 1 {
+		span = 1
+	}
+
+	// Block range queries (indexed on blockNumber)
+	blockRangeDurations := runTimedReads(config, "4.5. Block Range Queries", "Index: blockNumber", blockRangeQueryCount,
+		func(i int) ([]*Config.Transfer, error) {
+			startBlock := transactions[(i*7919)%len(transactions)].BlockNumber
+			return tableOps.QueryRecordsByBlockRange(ctx, startBlock, startBlock+span-1)
+		})
+
+	// Time range queries (ts is not indexed - full scan expected)
+	timeRangeDurations := runTimedReads(config, "4.6. Time Range Queries", "No Index (Full Scan)", timeRangeQueryCount,
+		func(i int) ([]*Config.Transfer, error) {
+			start := time.Unix(transactions[(i*7919)%len(transactions)].Timestamp, 0)
+			return tableOps.QueryRecordsByTimeRange(ctx, start, start.Add(time.Duration(config.TimeRangeSpan)*time.Second))
+		})
+
+	// FROM address + block range queries
+	addrRangeDurations := runTimedReads(config, "4.7. FROM Address + Block Range Queries", "Index: fromAddr", addrRangeQueryCount,
+		func(i int) ([]*Config.Transfer, error) {
+			startBlock := transactions[(i*7919)%len(transactions)].BlockNumber
+			return tableOps.QueryRecordsByFromInBlockRange(ctx, addresses.Account(), startBlock, startBlock+span-1)
+		})
+
+	// Combined address queries - first page of everything sent or received, self-transfers once
+	pageSize := config.PageSize
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	addressDurations := runTimedReads(config, "4.8. Address Queries (in + out)", "Index: address, blockNumber, txBlockIndex", addressQueryCount,
+		func(i int) ([]*Config.Transfer, error) {
+			page, err := tableOps.QueryRecordsByAddress(ctx, addresses.Account(), immusql.DirectionBoth, pageSize, "")
+			if err != nil {
+				return nil, err
+			}
+			return page.Records, nil
+		})
+
+	// Paginated address history - first page vs deep page, measured separately
+	firstPageDurations, deepPageDurations := runPaginationReads(ctx, tableOps, config)
+
 	// 5. Index Performance Summary
 	totalDuration := time.Since(overallStart)
 	fmt.Println("=== Index Performance Summary ===")
@@ -1334,6 +1397,36 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 		fmt.Printf("  Block Query (No Index):   P50=%v, P95=%v, P99=%v\n",
 			blockStats.P50, blockStats.P95, blockStats.P99)
 	}
+	if blockRangeQueryCount > 0 {
+		blockRangeStats := calculateLatencyStats(blockRangeDurations, config.EnablePercentiles)
+		fmt.Printf("  Block Range (Indexed):    P50=%v, P95=%v, P99=%v\n",
+			blockRangeStats.P50, blockRangeStats.P95, blockRangeStats.P99)
+	}
+	if timeRangeQueryCount > 0 {
+		timeRangeStats := calculateLatencyStats(timeRangeDurations, config.EnablePercentiles)
+		fmt.Printf("  Time Range (No Index):    P50=%v, P95=%v, P99=%v\n",
+			timeRangeStats.P50, timeRangeStats.P95, timeRangeStats.P99)
+	}
+	if addrRangeQueryCount > 0 {
+		addrRangeStats := calculateLatencyStats(addrRangeDurations, config.EnablePercentiles)
+		fmt.Printf("  FROM + Blocks (Indexed):  P50=%v, P95=%v, P99=%v\n",
+			addrRangeStats.P50, addrRangeStats.P95, addrRangeStats.P99)
+	}
+	if addressQueryCount > 0 {
+		addressStats := calculateLatencyStats(addressDurations, config.EnablePercentiles)
+		fmt.Printf("  Address in+out (Indexed): P50=%v, P95=%v, P99=%v\n",
+			addressStats.P50, addressStats.P95, addressStats.P99)
+	}
+	if len(firstPageDurations) > 0 {
+		firstPageStats := calculateLatencyStats(firstPageDurations, config.EnablePercentiles)
+		fmt.Printf("  Address Page 1:           P50=%v, P95=%v, P99=%v\n",
+			firstPageStats.P50, firstPageStats.P95, firstPageStats.P99)
+	}
+	if len(deepPageDurations) > 0 {
+		deepPageStats := calculateLatencyStats(deepPageDurations, config.EnablePercentiles)
+		fmt.Printf("  Address Page %-4d         P50=%v, P95=%v, P99=%v\n", config.DeepPage,
+			deepPageStats.P50, deepPageStats.P95, deepPageStats.P99)
+	}
 	fmt.Println()
 
 	fmt.Printf("Total Test Duration: %v\n", totalDuration)
@@ -1343,6 +1436,171 @@ func runIndexPerformanceTest(config IndexPerformanceConfig) {
 	fmt.Println()
 
 	fmt.Println("✓ Index performance test completed!")
+
+	query := func(name string, durations []time.Duration) QueryReport {
+		return newQueryReport(name, durations, config.EnablePercentiles, config.EnableDetailedStats)
+	}
+	totalCount, err := tableOps.CountAllRecords(ctx)
+	if err != nil {
+		fmt.Printf("  Note: could not count records for the report: %v\n", err)
+	}
+	report.Runs = []ReportRun{{
+		Name:         "indexed",
+		Server:       serverInfo(tableOps),
+		Insert:       newInsertStats(insertedCount, insertDuration),
+		TotalRecords: totalCount,
+		Queries: []QueryReport{
+			query(MetricHash, hashDurations),
+			query(MetricFrom, fromDurations),
+			query(MetricTo, toDurations),
+			query(MetricBlock, blockDurations),
+			query(MetricBlockRange, blockRangeDurations),
+			query(MetricTimeRange, timeRangeDurations),
+			query(MetricFromBlockRange, addrRangeDurations),
+			query(MetricAddress, addressDurations),
+			query(MetricAddressPage1, firstPageDurations),
+			query(MetricAddressPageN, deepPageDurations),
+		},
+		Timings: []Timing{
+			{MetricTableCreate, tableCreateDuration},
+		},
+	}}
+	return report.finish()
+}
+
+// splitByRatios splits n queries by ratios adding up to 1
+// The running total is rounded, so the counts add up to n and a zero ratio gets no queries
+func splitByRatios(n int, ratios ...float64) []int {
+	counts := make([]int, len(ratios))
+	cumulative, done := 0.0, 0
+	for i, ratio := range ratios {
+		cumulative += ratio
+		next := min(int(math.Round(float64(n)*cumulative)), n)
+		counts[i] = next - done
+		done = next
+	}
+	return counts
+}
+
+// runTimedReads runs count queries produced by query, printing progress and stats like the other read sections
+// Returns the latency of every query
+func runTimedReads(config IndexPerformanceConfig, title, index string, count int, query func(i int) ([]*Config.Transfer, error)) []time.Duration {
+	durations := make([]time.Duration, 0, count)
+	if count <= 0 {
+		return durations
+	}
+
+	fmt.Printf("  %s (%d) - %s\n", title, count, index)
+	var totalRecords int
+	for i := 0; i < count; i++ {
+		queryStart := time.Now()
+		records, err := query(i)
+		durations = append(durations, time.Since(queryStart))
+
+		if err != nil {
+			fatalf("%s failed: %v", title, err)
+		}
+		totalRecords += len(records)
+
+		if count > 20 && (i+1)%(count/5) == 0 {
+			fmt.Printf("    Progress: %d/%d - Avg records: %.1f\n",
+				i+1, count, float64(totalRecords)/float64(i+1))
+		}
+	}
+	stats := calculateLatencyStats(durations, config.EnablePercentiles)
+	fmt.Printf("  ✓ Queries completed (avg %.1f records per query)\n", float64(totalRecords)/float64(count))
+	if config.EnableDetailedStats {
+		printLatencyStats("    "+title, stats)
+	}
+	fmt.Println()
+	return durations
+}
+
+// runPaginationReads measures the first page and the deep page of FROM/TO address histories separately
+// Deep cursors are found once per address by walking the history (untimed), then reused for the timed queries
+func runPaginationReads(ctx context.Context, tableOps *immusql.TableOps, config IndexPerformanceConfig) ([]time.Duration, []time.Duration) {
+	if config.PageQueryCount <= 0 || config.PageSize <= 0 {
+		return nil, nil
+	}
+
+	type target struct {
+		address string
+		toAddr  bool
+	}
+	queryPage := func(tg target, cursor immusql.Cursor) (*immusql.Page, error) {
+		if tg.toAddr {
+			return tableOps.QueryRecordsByToPage(ctx, tg.address, config.PageSize, cursor)
+		}
+		return tableOps.QueryRecordsByFromPage(ctx, tg.address, config.PageSize, cursor)
+	}
+
+	// Alternate FROM and TO histories over the most frequent addresses of the table
+	keys, err := sampleLoadKeys(ctx, tableOps, 1000)
+	if err != nil {
+		fatalf("Failed to sample addresses: %v", err)
+	}
+	sample := keys.frequentAddresses(5)
+	targets := make([]target, 0, 2*len(sample))
+	for _, addr := range sample {
+		targets = append(targets, target{address: addr}, target{address: addr, toAddr: true})
+	}
+
+	fmt.Printf("  4.9. Paginated Address History (%d first page, %d page %d) - Index: address, blockNumber, txBlockIndex\n",
+		config.PageQueryCount, config.PageQueryCount, config.DeepPage)
+
+	// Walk to the deep page once per target; histories shorter than that stop at their last page
+	deepCursors := make([]immusql.Cursor, len(targets))
+	deepestPage := config.DeepPage
+	for i, tg := range targets {
+		var cursor immusql.Cursor
+		page := 1
+		for ; page < config.DeepPage; page++ {
+			result, err := queryPage(tg, cursor)
+			if err != nil {
+				fatalf("Failed to walk address history: %v", err)
+			}
+			if result.Next == "" {
+				break
+			}
+			cursor = result.Next
+		}
+		deepCursors[i] = cursor
+		if page < deepestPage {
+			deepestPage = page
+		}
+	}
+	if deepestPage < config.DeepPage {
+		fmt.Printf("    Note: some histories end before page %d; their last page (min %d) is used instead\n",
+			config.DeepPage, deepestPage)
+	}
+
+	firstDurations := make([]time.Duration, 0, config.PageQueryCount)
+	deepDurations := make([]time.Duration, 0, config.PageQueryCount)
+	for i := 0; i < config.PageQueryCount; i++ {
+		tg := targets[i%len(targets)]
+
+		queryStart := time.Now()
+		_, err := queryPage(tg, "")
+		firstDurations = append(firstDurations, time.Since(queryStart))
+		if err != nil {
+			fatalf("Failed to query first page: %v", err)
+		}
+
+		queryStart = time.Now()
+		_, err = queryPage(tg, deepCursors[i%len(targets)])
+		deepDurations = append(deepDurations, time.Since(queryStart))
+		if err != nil {
+			fatalf("Failed to query deep page: %v", err)
+		}
+	}
+
+	fmt.Printf("  ✓ Paginated queries completed\n")
+	if config.EnableDetailedStats {
+		printLatencyStats("    Address History Page 1", calculateLatencyStats(firstDurations, config.EnablePercentiles))
+		printLatencyStats(fmt.Sprintf("    Address History Page %d", config.DeepPage), calculateLatencyStats(deepDurations, config.EnablePercentiles))
+	}
+	fmt.Println()
+	return firstDurations, deepDurations
 }
 
 // printMenu displays the interactive menu
@@ -1356,11 +1614,17 @@ func printMenu() {
 	fmt.Println("  3. Run Performance Test (custom config)")
 	fmt.Println("  4. Run Index Performance Test (realistic workload)")
 	fmt.Println("  5. Benchmark: With Indexes vs Without Indexes")
+	fmt.Println("  6. Exit")
 	fmt.Println("  7. Run Compare Order By Test for testing Index Performance")
 	fmt.Println("  8. Add Trasnactions to the Table to test")
 	fmt.Println("  9. Print Table Stats")
-	fmt.Println("  6. Exit")
-	fmt.Print("\nEnter choice (1-6): ")
+	fmt.Println("  10. Concurrent Load Test (client sweep)")
+	fmt.Println("  11. Mixed Read/Write Workload (blocks appended while reading)")
+	fmt.Println("  12. Import Transfers from a JSONL or CSV File")
+	fmt.Println("  13. Export the Table to a JSONL or CSV File")
+	fmt.Println("  14. Verified vs Unverified Read Latency")
+	fmt.Println("  15. Historical vs Current Read Latency (time travel)")
+	fmt.Print("\nEnter choice (1-15): ")
 }
 
 // readInput reads a line from stdin
@@ -1368,7 +1632,7 @@ func readInput() string {
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
 	if err != nil {
-		log.Fatalf("Failed to read input: %v", err)
+		fatalf("Failed to read input: %v", err)
 	}
 	return strings.TrimSpace(input)
 }
@@ -1507,7 +1771,7 @@ func runInteractiveCLI() {
 
 		case "5":
 			fmt.Println()
-			runIndexBenchmarkComparison()
+			runIndexBenchmarkComparison(DefaultBenchmarkConfig(), true)
 			fmt.Println("\nPress Enter to continue...")
 			readInput()
 
@@ -1523,62 +1787,145 @@ func runInteractiveCLI() {
 
 		case "8":
 			fmt.Println()
-			AddtransactionsToDB()
+			if err := AddtransactionsToDB(DefaultAddTxnsConfig()); err != nil {
+				fmt.Println(err)
+			}
 			fmt.Println("\nPress Enter to continue...")
 			readInput()
 
 		case "9":
 			fmt.Println()
-			
+			RunStats()
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "10":
+			fmt.Println()
+			config := DefaultLoadConfig()
+			config.Sweep = true
+			runConcurrentLoadTest(config)
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "11":
+			fmt.Println()
+			runMixedWorkload(DefaultMixedWorkloadConfig())
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "12":
+			fmt.Print("\nFile to import: ")
+			config := DefaultImportConfig()
+			config.Path = readInput()
+			fmt.Println()
+			if _, err := runImport(config); err != nil {
+				fmt.Println(err)
+			}
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "13":
+			fmt.Print("\nFile to export to: ")
+			config := DefaultExportConfig()
+			config.Path = readInput()
+			fmt.Println()
+			if err := runExport(config); err != nil {
+				fmt.Println(err)
+			}
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "14":
+			fmt.Println()
+			runVerifyBenchmark(DefaultVerifyConfig())
+			fmt.Println("\nPress Enter to continue...")
+			readInput()
+
+		case "15":
+			fmt.Println()
+			runTimeTravelBenchmark(DefaultTimeTravelConfig())
 			fmt.Println("\nPress Enter to continue...")
 			readInput()
 
 		default:
-			fmt.Printf("\nInvalid choice: %s. Please enter 1-6.\n", choice)
+			fmt.Printf("\nInvalid choice: %s. Please enter 1-15.\n", choice)
 			time.Sleep(1 * time.Second)
 		}
 	}
 }
 
-func RunStats(){
+// RunStats prints the aggregate statistics of the table
+func RunStats() {
+	runStats(immusql.AsOf{})
+}
+
+// runStats prints the table statistics as of the point (the zero point is now)
+func runStats(asOf immusql.AsOf) {
 	fmt.Println("Printing Table Stats...")
 	ctx := context.Background()
-	tableOps := immusql.GetTableOps()
-	
+	tableOps := immusql.GetTableOps(appSettings).AsOf(asOf)
+
 	// Get table statistics
 	stats, err := tableOps.GetTableStatistics(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get table statistics: %v", err)
+		fatalf("Failed to get table statistics: %v", err)
 	}
-	fmt.Println("Table Statistics:")
-	fmt.Println(stats)
+	if asOf.IsZero() {
+		fmt.Printf("Table Statistics (%s):\n", tableOps.TableName())
+	} else {
+		fmt.Printf("Table Statistics (%s, as of %s):\n", tableOps.TableName(), asOf)
+	}
+	fmt.Printf("  Total Records:     %d\n", stats.TotalRecords)
+	if stats.TotalRecords == 0 {
+		return
+	}
+	fmt.Printf("  Block Range:       %d - %d\n", stats.MinBlockNumber, stats.MaxBlockNumber)
+	fmt.Printf("  Time Range:        %s - %s\n",
+		time.Unix(stats.MinTimestamp, 0).UTC().Format(time.RFC3339),
+		time.Unix(stats.MaxTimestamp, 0).UTC().Format(time.RFC3339))
+	fmt.Printf("  Unique FROM Addrs: %s\n", uniqueCount(stats.UniqueFromAddrs))
+	fmt.Printf("  Unique TO Addrs:   %s\n", uniqueCount(stats.UniqueToAddrs))
+}
+
+// uniqueCount formats a TableStatistics unique count, which is -1 when the server could not compute it
+func uniqueCount(n int) string {
+	if n < 0 {
+		return "n/a"
+	}
+	return strconv.Itoa(n)
+}
+
+// fatalf logs like log.Fatalf, but first closes connections and stops any embedded immudb (removing
+// its temp dir), which the deferred IMMUDB.CloseAll in main would not do on the way out through os.Exit
+func fatalf(format string, v ...any) {
+	IMMUDB.CloseAll()
+	log.Fatalf(format, v...)
 }
 
+// appSettings holds the connection and table settings resolved at startup by Config.Load
+var appSettings *Config.Settings
+
 func main() {
-	// Check for command-line arguments for non-interactive mode
-	if len(os.Args) > 1 {
-		command := strings.ToLower(os.Args[1])
-		switch command {
-		case "query", "state", "status":
-			queryTableState()
-		case "test", "perf", "performance":
-			config := DefaultTestConfig()
-			runPerformanceTest(config)
-		case "benchmark", "bench", "compare":
-			runIndexBenchmarkComparison()
-		case "compareorderby": // For the non-interactive compare order by test
-            runCompareOrderByTest()
-		case "help", "-h", "--help":
-			fmt.Println("Usage:")
-			fmt.Println("  go run simulator.go              - Interactive mode")
-			fmt.Println("  go run simulator.go query         - Query table state")
-			fmt.Println("  go run simulator.go test          - Run performance test")
-			fmt.Println("  go run simulator.go benchmark     - Run index benchmark (non-interactive)")
-			fmt.Println("  go run simulator.go help          - Show this help")
-		default:
-			fmt.Printf("Unknown command: %s\n", command)
-			fmt.Println("Use 'help' to see available commands")
-			os.Exit(1)
+	// Resolve settings from defaults, config file, IMMUDB_* env vars and global flags
+	settings, args, err := Config.Load(os.Args[1:])
+	if err != nil {
+		fatalf("Failed to load configuration: %v", err)
+	}
+	appSettings = settings
+	// Pick the data seed once, so every scenario of this process (and a rerun with -seed) sees the same data
+	if appSettings.Seed == 0 {
+		appSettings.Seed = Generator.RandomSeed()
+	}
+	fmt.Printf("Seed: %d (rerun with -seed %d for the same data)\n", appSettings.Seed, appSettings.Seed)
+	// Close connections and stop any embedded immudb (removing its temp dir) on the way out
+	defer IMMUDB.CloseAll()
+
+	// A command on the command line runs non-interactively (see cli.go)
+	if len(args) > 0 {
+		code := runCommand(args)
+		if code != 0 {
+			IMMUDB.CloseAll() // os.Exit skips deferred calls
+			os.Exit(code)
 		}
 		return
 	}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Historical reads (see IMMUSQL/AsOf.go) against current ones: the table is seeded in Snapshots
+  stages and the tx after each stage is kept as a point in history; every lookup then runs on
+  the current table and as of every point
+- A writer appends one block every BlockTime while the lookups run (-block-time 0 for reads
+  only), which is the case an explorer showing historic views is in
+- Before and after the lookups, the count and block range as of every point are checked against
+  what the table held when the point was taken: any difference stops the run
+*/
+
+// TimeTravelConfig holds configuration for the historical read benchmark
+type TimeTravelConfig struct {
+	TotalTransactions   int           // Transactions to seed the table with
+	TxnsPerBlock        int           // Transactions per block, seeded and appended (max 200)
+	StartBlockNumber    int           // Starting block number
+	Snapshots           int           // Points in history, one after each equal stage of the seeding
+	Queries             int           // Lookups per query type and point
+	KeySampleSize       int           // Records sampled from the table to pick query keys from
+	BlockTime           time.Duration // Interval between blocks appended while the lookups run (0 = no writer)
+	EnablePercentiles   bool          // Calculate latency percentiles
+	EnableDetailedStats bool          // Print the full statistics of every query type and point
+}
+
+// DefaultTimeTravelConfig returns the default historical read configuration
+func DefaultTimeTravelConfig() TimeTravelConfig {
+	return TimeTravelConfig{
+		TotalTransactions:   20000,
+		TxnsPerBlock:        200,
+		StartBlockNumber:    1000000,
+		Snapshots:           4,
+		Queries:             200,
+		KeySampleSize:       1000,
+		BlockTime:           time.Second,
+		EnablePercentiles:   true,
+		EnableDetailedStats: false,
+	}
+}
+
+// timeTravelPoint is a point in history and what the table held at it
+type timeTravelPoint struct {
+	name     string
+	asOf     immusql.AsOf
+	records  int
+	maxBlock int
+}
+
+// check compares the table as of p with what it held when p was taken
+func (p timeTravelPoint) check(ctx context.Context, tableOps *immusql.TableOps) error {
+	stats, err := tableOps.AsOf(p.asOf).GetTableStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.TotalRecords != p.records || stats.MaxBlockNumber != p.maxBlock {
+		return fmt.Errorf("%s: %d records up to block %d, want %d up to block %d",
+			p.name, stats.TotalRecords, stats.MaxBlockNumber, p.records, p.maxBlock)
+	}
+	return nil
+}
+
+// timeTravelQuery is one query type, run at any point
+type timeTravelQuery struct {
+	metric string
+	title  string
+	run    func(ctx context.Context, ops *immusql.TableOps, i int) error
+}
+
+// runTimeTravelBenchmark measures the latency of reads as of past transactions against current reads
+func runTimeTravelBenchmark(config TimeTravelConfig) *Report {
+	ctx := context.Background()
+	report := newReport("timetravel", config)
+	tableOps := immusql.GetTableOps(appSettings)
+
+	fmt.Println("=== Historical Read Benchmark ===")
+	fmt.Println()
+	fmt.Println("Test Configuration:")
+	fmt.Printf("  Seed:     %d transactions in %d stages, %d per block\n", config.TotalTransactions, config.Snapshots, config.TxnsPerBlock)
+	fmt.Printf("  Lookups:  %d per query type, now and as of each stage\n", config.Queries)
+	if config.BlockTime > 0 {
+		fmt.Printf("  Writer:   one block every %v during the lookups\n", config.BlockTime)
+	}
+	fmt.Println()
+
+	// 1. Seed in stages, keeping the tx after each
+	fmt.Printf("1. Seeding table '%s'...\n", tableOps.TableName())
+	if err := tableOps.DropTable(ctx); err != nil {
+		fatalf("Failed to drop table: %v", err)
+	}
+	if err := tableOps.CreateTable(ctx); err != nil {
+		fatalf("Failed to create table: %v", err)
+	}
+	gen := newGenerator()
+	seed := gen.BlockTransactions(config.TotalTransactions, config.TxnsPerBlock, config.StartBlockNumber)
+	var points []timeTravelPoint
+	insertStart := time.Now()
+	for stage := range config.Snapshots {
+		// Stages end on block boundaries, so a point never splits a block
+		blocks := (len(seed) + config.TxnsPerBlock - 1) / config.TxnsPerBlock
+		end := min(len(seed), (stage+1)*blocks/config.Snapshots*config.TxnsPerBlock)
+		if stage == config.Snapshots-1 {
+			end = len(seed)
+		}
+		start := 0
+		if len(points) > 0 {
+			start = points[len(points)-1].records
+		}
+		if err := tableOps.InsertRecords(ctx, seed[start:end]); err != nil {
+			fatalf("Failed to insert records: %v", err)
+		}
+		tx, err := tableOps.SnapshotTx(ctx)
+		if err != nil {
+			fatalf("Failed to get the snapshot tx: %v", err)
+		}
+		point := timeTravelPoint{asOf: immusql.AsOfTx(tx), records: end}
+		point.name = point.asOf.String()
+		if end > 0 {
+			point.maxBlock = seed[end-1].BlockNumber
+		}
+		points = append(points, point)
+		fmt.Printf("  ✓ %s: %d records, up to block %d\n", point.name, point.records, point.maxBlock)
+	}
+	insert := newInsertStats(len(seed), time.Since(insertStart))
+	fmt.Printf("✓ Inserted %d records in %v (%.2f tx/s)\n\n", insert.Records, insert.Duration, insert.Rate)
+
+	// Keys from the start of the table exist at every point
+	sample, err := tableOps.GetSampleRecords(ctx, min(config.KeySampleSize, points[0].records))
+	if err != nil {
+		fatalf("Failed to sample query keys: %v", err)
+	}
+	if len(sample) == 0 {
+		fatalf("No records to sample query keys from")
+	}
+	keys := newLoadKeys(sample)
+	addresses := queryAddresses()
+	targets := make([]string, config.Queries)
+	for i := range targets {
+		targets[i] = addresses.Account()
+	}
+	queries := []timeTravelQuery{
+		{MetricHash, "Transaction Hash", func(ctx context.Context, ops *immusql.TableOps, i int) error {
+			_, err := ops.QueryRecord(ctx, keys.hashes[i%len(keys.hashes)])
+			return err
+		}},
+		{MetricFrom, "FROM Address", func(ctx context.Context, ops *immusql.TableOps, i int) error {
+			_, err := ops.QueryRecordsByFrom(ctx, targets[i])
+			return err
+		}},
+		{MetricTo, "TO Address", func(ctx context.Context, ops *immusql.TableOps, i int) error {
+			_, err := ops.QueryRecordsByTo(ctx, targets[i])
+			return err
+		}},
+		{MetricBlock, "Block Number", func(ctx context.Context, ops *immusql.TableOps, i int) error {
+			_, err := ops.QueryRecordsByBlockNumber(ctx, keys.blocks[i%len(keys.blocks)])
+			return err
+		}},
+		{MetricCountFrom, "Count FROM", func(ctx context.Context, ops *immusql.TableOps, i int) error {
+			_, err := ops.CountRecords(ctx, targets[i])
+			return err
+		}},
+	}
+
+	for _, point := range points {
+		if err := point.check(ctx, tableOps); err != nil {
+			fatalf("Historical read is wrong before the run: %v", err)
+		}
+	}
+
+	// The writer appends blocks until the lookups are done
+	var writer sync.WaitGroup
+	var appended atomic.Int64
+	stopWriter := make(chan struct{})
+	if config.BlockTime > 0 {
+		nextBlock := points[len(points)-1].maxBlock + 1
+		writer.Add(1)
+		go func() {
+			defer writer.Done()
+			ticker := time.NewTicker(config.BlockTime)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stopWriter:
+					return
+				case <-ticker.C:
+				}
+				block := gen.BlockTransactions(config.TxnsPerBlock, config.TxnsPerBlock, nextBlock)
+				if err := tableOps.InsertRecords(ctx, block); err != nil {
+					fatalf("Failed to insert block %d: %v", nextBlock, err)
+				}
+				nextBlock++
+				appended.Add(int64(len(block)))
+			}
+		}()
+	}
+
+	// 2. Every lookup now and at every point, rotating which goes first
+	fmt.Println("2. Running lookups...")
+	views := append([]timeTravelPoint{{name: "now"}}, points...)
+	latencies := make([]map[string][]time.Duration, len(views))
+	for v := range views {
+		latencies[v] = map[string][]time.Duration{}
+	}
+	for _, query := range queries {
+		for i := 0; i < config.Queries; i++ {
+			for k := range views {
+				v := (i + k) % len(views)
+				ops := tableOps.AsOf(views[v].asOf)
+				start := time.Now()
+				if err := query.run(ctx, ops, i); err != nil {
+					fatalf("%s lookup as of %s failed: %v", query.title, views[v].name, err)
+				}
+				latencies[v][query.metric] = append(latencies[v][query.metric], time.Since(start))
+			}
+		}
+		fmt.Printf("  ✓ %s: %d lookups at %d points\n", query.title, config.Queries, len(views))
+		if config.EnableDetailedStats {
+			for v, view := range views {
+				printLatencyStats(fmt.Sprintf("%s (%s)", query.title, view.name),
+					calculateLatencyStats(latencies[v][query.metric], config.EnablePercentiles))
+			}
+		}
+	}
+	close(stopWriter)
+	writer.Wait()
+	if config.BlockTime > 0 {
+		fmt.Printf("  ✓ %d records appended during the lookups\n", appended.Load())
+	}
+
+	for _, point := range points {
+		if err := point.check(ctx, tableOps); err != nil {
+			fatalf("Historical read changed during the run: %v", err)
+		}
+	}
+	fmt.Printf("  ✓ Counts and block ranges as of all %d points unchanged\n", len(points))
+
+	// 3. Summary: P50 per query type and point
+	fmt.Println()
+	fmt.Println("=== Historical vs Current Reads (P50) ===")
+	fmt.Println()
+	header := fmt.Sprintf("  %-18s", "Query")
+	for _, view := range views {
+		header += fmt.Sprintf(" %12s", view.name)
+	}
+	fmt.Println(header)
+	for _, query := range queries {
+		line := fmt.Sprintf("  %-18s", query.title)
+		for v := range views {
+			line += fmt.Sprintf(" %12v", calculateLatencyStats(latencies[v][query.metric], true).P50)
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	server := serverInfo(tableOps)
+	for v, view := range views {
+		reportRun := ReportRun{Name: reportRunName(view.name), Server: server, Insert: insert, TotalRecords: view.records}
+		if v == 0 {
+			reportRun.TotalRecords = len(seed) + int(appended.Load())
+		}
+		for _, query := range queries {
+			reportRun.Queries = append(reportRun.Queries,
+				newQueryReport(query.metric, latencies[v][query.metric], config.EnablePercentiles, config.EnableDetailedStats))
+		}
+		report.Runs = append(report.Runs, reportRun)
+	}
+
+	fmt.Println("✓ Historical read benchmark completed!")
+	return report.finish()
+}
+
+// reportRunName turns a point name ("tx 12") into a report run name ("tx_12")
+func reportRunName(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// parseAsOf parses a point in history: a tx id ("123" or "tx:123"), or a commit time in a format parseImportTime reads
+func parseAsOf(s string) (immusql.AsOf, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return immusql.AsOf{}, nil
+	}
+	if tx, err := strconv.ParseUint(strings.TrimPrefix(s, "tx:"), 10, 64); err == nil {
+		return immusql.AsOfTx(tx), nil
+	}
+	ts, err := parseImportTime(s)
+	if err != nil {
+		return immusql.AsOf{}, fmt.Errorf("not a tx id or a time: %q", s)
+	}
+	return immusql.AsOfTime(time.Unix(ts, 0)), nil
+}
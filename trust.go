@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"DBTests/IMMUDB"
+)
+
+/*
+- Shows the trusted state of the active profile: the last database state the client verified,
+  which ConnectDB and every verified read check the server against (see IMMUDB/state.go)
+- -forget drops it without connecting, after the server was reset or replaced on purpose;
+  the next connection trusts the server's state again
+*/
+
+// runTrust checks the server against the trusted state, or forgets it
+func runTrust(forget bool) error {
+	profile, err := appSettings.Resolve(appSettings.ActiveProfile)
+	if err != nil {
+		return err
+	}
+	if forget {
+		if err := IMMUDB.ForgetTrustedState(profile); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Forgot the trusted state of %s [%s]\n", profile.Database, profile.Name)
+		return nil
+	}
+
+	if _, err := IMMUDB.ConnectDB(profile); err != nil {
+		return err
+	}
+	check, err := IMMUDB.CheckTrustedState(context.Background(), profile)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Println("=== Trusted State ===")
+	fmt.Println()
+	fmt.Printf("  Server:   %s\n", check.ServerUUID)
+	fmt.Printf("  Address:  %s\n", check.Address)
+	fmt.Printf("  Database: %s\n", check.Database)
+	fmt.Printf("  Trusted:  tx %d\n", check.ServerTx)
+	return nil
+}
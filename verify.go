@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"DBTests/Config"
+	immusql "DBTests/IMMUSQL"
+)
+
+/*
+- Cost of verification: every lookup runs twice on the same key, once plain (QueryRecord*) and
+  once verified (VerifiedQueryRecord*), and the two latency distributions are compared
+- The order of the pair alternates, so neither side always runs with warm caches
+- Verified lookups add one proof round trip per returned row, so the per-row overhead is
+  reported next to the per-query one: address and block lookups scale with their row count
+- A proof mismatch stops the run with a tamper alert instead of being counted as an error
+*/
+
+// VerifyConfig holds configuration for the verified read benchmark
+type VerifyConfig struct {
+	TotalTransactions   int  // Transactions to seed the table with (0 = use the existing data)
+	TxnsPerBlock        int  // Transactions per block when seeding
+	StartBlockNumber    int  // Starting block number when seeding
+	Queries             int  // Lookups per query type, each run plain and verified
+	KeySampleSize       int  // Records sampled from the table to pick query keys from
+	EnablePercentiles   bool // Calculate latency percentiles
+	EnableDetailedStats bool // Print the full statistics of every query type
+}
+
+// DefaultVerifyConfig returns the default verified read configuration
+func DefaultVerifyConfig() VerifyConfig {
+	return VerifyConfig{
+		TotalTransactions:   5000,
+		TxnsPerBlock:        100,
+		StartBlockNumber:    1000000,
+		Queries:             200,
+		KeySampleSize:       1000,
+		EnablePercentiles:   true,
+		EnableDetailedStats: false,
+	}
+}
+
+// verifyLookup is one query type with its plain and verified implementation
+type verifyLookup struct {
+	metric   string
+	title    string
+	plain    func(ctx context.Context, i int) ([]*Config.Transfer, error)
+	verified func(ctx context.Context, i int) ([]*Config.Transfer, error)
+}
+
+// verifyResult holds the latencies of one query type
+type verifyResult struct {
+	lookup   verifyLookup
+	plain    []time.Duration
+	verified []time.Duration
+	rows     int // rows returned by the verified lookups
+}
+
+// perRowOverhead returns the extra mean latency of verification divided by the rows it verified
+func (r verifyResult) perRowOverhead() time.Duration {
+	if r.rows == 0 {
+		return 0
+	}
+	extra := calculateLatencyStats(r.verified, false).Mean - calculateLatencyStats(r.plain, false).Mean
+	return extra * time.Duration(len(r.verified)) / time.Duration(r.rows)
+}
+
+// verifyLookups returns the query types of the benchmark; i selects the key
+func verifyLookups(tableOps *immusql.TableOps, keys *loadKeys, addresses []string) []verifyLookup {
+	one := func(record *Config.Transfer, err error) ([]*Config.Transfer, error) {
+		if record == nil {
+			return nil, err
+		}
+		return []*Config.Transfer{record}, err
+	}
+	hash := func(i int) string { return keys.hashes[i%len(keys.hashes)] }
+	address := func(i int) string { return addresses[i%len(addresses)] }
+	block := func(i int) int { return keys.blocks[i%len(keys.blocks)] }
+
+	return []verifyLookup{
+		{MetricHash, "Transaction Hash",
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return one(tableOps.QueryRecord(ctx, hash(i)))
+			},
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return one(tableOps.VerifiedQueryRecord(ctx, hash(i)))
+			}},
+		{MetricFrom, "FROM Address",
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.QueryRecordsByFrom(ctx, address(i))
+			},
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.VerifiedQueryRecordsByFrom(ctx, address(i))
+			}},
+		{MetricTo, "TO Address",
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.QueryRecordsByTo(ctx, address(i))
+			},
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.VerifiedQueryRecordsByTo(ctx, address(i))
+			}},
+		{MetricBlock, "Block Number",
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.QueryRecordsByBlockNumber(ctx, block(i))
+			},
+			func(ctx context.Context, i int) ([]*Config.Transfer, error) {
+				return tableOps.VerifiedQueryRecordsByBlockNumber(ctx, block(i))
+			}},
+	}
+}
+
+// runVerifyBenchmark measures the latency of verified reads against plain ones
+func runVerifyBenchmark(config VerifyConfig) *Report {
+	ctx := context.Background()
+	report := newReport("verify", config)
+	tableOps := immusql.GetTableOps(appSettings)
+
+	fmt.Println("=== Verified Read Benchmark ===")
+	fmt.Println()
+	fmt.Println("Test Configuration:")
+	fmt.Printf("  Lookups:  %d per query type, each plain and verified\n", config.Queries)
+	fmt.Printf("  Keys:     hashes, blocks and addresses of %d sampled records\n", config.KeySampleSize)
+	fmt.Println()
+
+	// 1. Seed the table, or use what is there
+	var insert InsertStats
+	if config.TotalTransactions > 0 {
+		fmt.Printf("1. Seeding table '%s' with %d transactions...\n", tableOps.TableName(), config.TotalTransactions)
+		if err := tableOps.DropTable(ctx); err != nil {
+			fatalf("Failed to drop table: %v", err)
+		}
+		if err := tableOps.CreateTable(ctx); err != nil {
+			fatalf("Failed to create table: %v", err)
+		}
+		transactions := newGenerator().BlockTransactions(config.TotalTransactions, config.TxnsPerBlock, config.StartBlockNumber)
+		insertStart := time.Now()
+		if err := tableOps.InsertRecords(ctx, transactions); err != nil {
+			fatalf("Failed to insert records: %v", err)
+		}
+		insert = newInsertStats(len(transactions), time.Since(insertStart))
+		fmt.Printf("✓ Inserted %d records in %v (%.2f tx/s)\n\n", insert.Records, insert.Duration, insert.Rate)
+	} else {
+		fmt.Printf("1. Using existing data in table '%s'\n\n", tableOps.TableName())
+	}
+
+	keys, err := sampleLoadKeys(ctx, tableOps, config.KeySampleSize)
+	if err != nil {
+		fatalf("Failed to sample query keys: %v", err)
+	}
+	totalCount, err := tableOps.CountAllRecords(ctx)
+	if err != nil {
+		fatalf("Failed to count records: %v", err)
+	}
+	targets := make([]string, config.Queries)
+	for i := range targets {
+		targets[i] = keys.from[i%len(keys.from)]
+	}
+
+	// 2. Every lookup plain and verified, alternating which goes first
+	fmt.Println("2. Running lookups...")
+	var results []verifyResult
+	for _, lookup := range verifyLookups(tableOps, keys, targets) {
+		result := verifyResult{lookup: lookup}
+		for i := 0; i < config.Queries; i++ {
+			measure := func(verified bool) {
+				query := lookup.plain
+				if verified {
+					query = lookup.verified
+				}
+				start := time.Now()
+				records, err := query(ctx, i)
+				elapsed := time.Since(start)
+				if errors.Is(err, immusql.ErrVerification) {
+					fatalf("TAMPER ALERT: %s lookup failed verification: %v", lookup.title, err)
+				}
+				if err != nil {
+					fatalf("%s lookup failed: %v", lookup.title, err)
+				}
+				if verified {
+					result.verified = append(result.verified, elapsed)
+					result.rows += len(records)
+				} else {
+					result.plain = append(result.plain, elapsed)
+				}
+			}
+			first := i%2 == 0
+			measure(!first)
+			measure(first)
+		}
+		results = append(results, result)
+		fmt.Printf("  ✓ %s: %d lookups, %d rows verified\n", lookup.title, config.Queries, result.rows)
+		if config.EnableDetailedStats {
+			printLatencyStats(lookup.title+" (plain)", calculateLatencyStats(result.plain, config.EnablePercentiles))
+			printLatencyStats(lookup.title+" (verified)", calculateLatencyStats(result.verified, config.EnablePercentiles))
+		}
+	}
+
+	// 3. Summary
+	fmt.Println()
+	fmt.Println("=== Verification Cost ===")
+	fmt.Println()
+	fmt.Printf("  %-18s %9s %12s %13s %12s %13s %12s\n", "Query", "Rows/Op", "Plain P50", "Verified P50", "Plain Mean", "Verified Mean", "Per Row")
+	for _, r := range results {
+		plain := calculateLatencyStats(r.plain, true)
+		verified := calculateLatencyStats(r.verified, true)
+		fmt.Printf("  %-18s %9.1f %12v %13v %12v %13v %12v\n",
+			r.lookup.title, float64(r.rows)/float64(config.Queries),
+			plain.P50, verified.P50, plain.Mean, verified.Mean, r.perRowOverhead())
+	}
+	fmt.Println()
+	fmt.Println("  Per Row: extra mean latency of verification divided by the rows it verified")
+	fmt.Println()
+
+	server := serverInfo(tableOps)
+	for _, run := range []struct {
+		name     string
+		verified bool
+	}{{"unverified", false}, {"verified", true}} {
+		reportRun := ReportRun{Name: run.name, Server: server, Insert: insert, TotalRecords: totalCount}
+		for _, r := range results {
+			durations := r.plain
+			if run.verified {
+				durations = r.verified
+			}
+			reportRun.Queries = append(reportRun.Queries,
+				newQueryReport(r.lookup.metric, durations, config.EnablePercentiles, config.EnableDetailedStats))
+		}
+		report.Runs = append(report.Runs, reportRun)
+	}
+
+	fmt.Println("✓ Verified read benchmark completed!")
+	return report.finish()
+}